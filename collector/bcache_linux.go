@@ -0,0 +1,292 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nobcache
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	bcacheSubsystem = "bcache"
+	// Units used by bch_hprint, each a power of 1024 of the previous one.
+	bcacheUnits = "kMGTPEZY"
+)
+
+var (
+	// Windows of the stats_* directories, by their directory suffix.
+	bcacheWindows = []string{"five_minute", "hour", "day", "total"}
+
+	// Files of the stats_* directories. The values of all but the total
+	// window decay over time, so they are all exported as gauges.
+	bcacheWindowFields = []struct {
+		file, name, help string
+		parse            func(string) (float64, error)
+	}{
+		{"bypassed", "bypassed_bytes", "Amount of IO that bypassed the cache.", parseBcacheValue},
+		{"cache_hits", "cache_hits", "Hits counted per individual IO as bcache sees them.", parseBcacheValue},
+		{"cache_misses", "cache_misses", "Misses counted per individual IO as bcache sees them.", parseBcacheValue},
+		{"cache_hit_ratio", "cache_hit_ratio", "Ratio of cache hits to all cache lookups.", parseBcachePercent},
+		{"cache_bypass_hits", "cache_bypass_hits", "Hits for IO intended to skip the cache.", parseBcacheValue},
+		{"cache_bypass_misses", "cache_bypass_misses", "Misses for IO intended to skip the cache.", parseBcacheValue},
+		{"cache_miss_collisions", "cache_miss_collisions", "Instances where data insertion from cache miss raced with write.", parseBcacheValue},
+		{"cache_readaheads", "cache_readaheads", "Count of times readahead occurred.", parseBcacheValue},
+	}
+)
+
+type bcacheBackingDevice struct {
+	name          string
+	dirtyData     float64                       // bytes
+	writebackRate float64                       // bytes per second
+	windows       map[string]map[string]float64 // window -> file -> value
+}
+
+type bcacheStats struct {
+	uuid           string
+	btreeCacheSize float64 // bytes
+	congested      float64
+	cacheAvailable float64                       // ratio
+	windows        map[string]map[string]float64 // window -> file -> value
+	backingDevices []bcacheBackingDevice
+}
+
+type bcacheCollector struct {
+	btreeCacheSizeDesc, congestedDesc, cacheAvailableDesc,
+	dirtyDataDesc, writebackRateDesc *prometheus.Desc
+	windowDescs, backingDeviceWindowDescs []*prometheus.Desc
+}
+
+func init() {
+	Factories["bcache"] = NewBcacheCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// bcache statistics.
+func NewBcacheCollector() (Collector, error) {
+	var windowDescs, backingDeviceWindowDescs []*prometheus.Desc
+	for _, f := range bcacheWindowFields {
+		windowDescs = append(windowDescs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bcacheSubsystem, f.name),
+			f.help+" Per cache set and window.",
+			[]string{"uuid", "window"}, nil,
+		))
+		backingDeviceWindowDescs = append(backingDeviceWindowDescs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bcacheSubsystem, "backing_device_"+f.name),
+			f.help+" Per backing device and window.",
+			[]string{"uuid", "backing_device", "window"}, nil,
+		))
+	}
+	return &bcacheCollector{
+		btreeCacheSizeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bcacheSubsystem, "btree_cache_size_bytes"),
+			"Amount of memory currently used by the btree cache.",
+			[]string{"uuid"}, nil,
+		),
+		congestedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bcacheSubsystem, "congested"),
+			"Congestion of the cache set, 0 if it is not congested.",
+			[]string{"uuid"}, nil,
+		),
+		cacheAvailableDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bcacheSubsystem, "cache_available_ratio"),
+			"Ratio of the cache set that doesn't contain dirty data and could be used for writeback.",
+			[]string{"uuid"}, nil,
+		),
+		dirtyDataDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bcacheSubsystem, "dirty_data_bytes"),
+			"Amount of dirty data for the backing device in the cache.",
+			[]string{"uuid", "backing_device"}, nil,
+		),
+		writebackRateDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bcacheSubsystem, "writeback_rate_bytes"),
+			"Rate in bytes per second at which dirty data is written back to the backing device.",
+			[]string{"uuid", "backing_device"}, nil,
+		),
+		windowDescs:              windowDescs,
+		backingDeviceWindowDescs: backingDeviceWindowDescs,
+	}, nil
+}
+
+func (c *bcacheCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	stats, err := getBcacheStats()
+	if err != nil {
+		return fmt.Errorf("couldn't get bcache stats: %s", err)
+	}
+	for _, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.btreeCacheSizeDesc, prometheus.GaugeValue, s.btreeCacheSize, s.uuid)
+		ch <- prometheus.MustNewConstMetric(c.congestedDesc, prometheus.GaugeValue, s.congested, s.uuid)
+		ch <- prometheus.MustNewConstMetric(c.cacheAvailableDesc, prometheus.GaugeValue, s.cacheAvailable, s.uuid)
+		for window, values := range s.windows {
+			for i, f := range bcacheWindowFields {
+				ch <- prometheus.MustNewConstMetric(c.windowDescs[i], prometheus.GaugeValue, values[f.file], s.uuid, window)
+			}
+		}
+
+		for _, b := range s.backingDevices {
+			ch <- prometheus.MustNewConstMetric(c.dirtyDataDesc, prometheus.GaugeValue, b.dirtyData, s.uuid, b.name)
+			ch <- prometheus.MustNewConstMetric(c.writebackRateDesc, prometheus.GaugeValue, b.writebackRate, s.uuid, b.name)
+			for window, values := range b.windows {
+				for i, f := range bcacheWindowFields {
+					ch <- prometheus.MustNewConstMetric(c.backingDeviceWindowDescs[i], prometheus.GaugeValue, values[f.file], s.uuid, b.name, window)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func getBcacheStats() ([]bcacheStats, error) {
+	// Besides one directory per cache set UUID, /sys/fs/bcache also
+	// contains the register files.
+	cacheSets, err := filepath.Glob(sysFilePath("fs/bcache/*-*-*-*-*"))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]bcacheStats, 0, len(cacheSets))
+	for _, cs := range cacheSets {
+		s, err := readBcacheStats(cs)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+func readBcacheStats(dir string) (bcacheStats, error) {
+	s := bcacheStats{uuid: path.Base(dir)}
+
+	for file, value := range map[string]*float64{
+		"btree_cache_size": &s.btreeCacheSize,
+		"congested":        &s.congested,
+	} {
+		v, err := readBcacheFile(path.Join(dir, file), parseBcacheValue)
+		if err != nil {
+			return s, err
+		}
+		*value = v
+	}
+	v, err := readBcacheFile(path.Join(dir, "cache_available_percent"), parseBcachePercent)
+	if err != nil {
+		return s, err
+	}
+	s.cacheAvailable = v
+
+	s.windows, err = readBcacheWindows(dir)
+	if err != nil {
+		return s, err
+	}
+
+	// The bdev<n> links point to the bcache directory of the backing
+	// device in sysfs, e.g. /sys/devices/.../block/sdb/bcache.
+	bdevs, err := filepath.Glob(path.Join(dir, "bdev[0-9]*"))
+	if err != nil {
+		return s, err
+	}
+	for _, bdev := range bdevs {
+		b, err := readBcacheBackingDevice(bdev)
+		if err != nil {
+			return s, err
+		}
+		s.backingDevices = append(s.backingDevices, b)
+	}
+	return s, nil
+}
+
+func readBcacheBackingDevice(link string) (bcacheBackingDevice, error) {
+	var b bcacheBackingDevice
+
+	dir, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		return b, err
+	}
+	b.name = path.Base(path.Dir(dir))
+
+	for file, value := range map[string]*float64{
+		"dirty_data":     &b.dirtyData,
+		"writeback_rate": &b.writebackRate,
+	} {
+		v, err := readBcacheFile(path.Join(dir, file), parseBcacheValue)
+		if err != nil {
+			return b, err
+		}
+		*value = v
+	}
+
+	b.windows, err = readBcacheWindows(dir)
+	return b, err
+}
+
+func readBcacheWindows(dir string) (map[string]map[string]float64, error) {
+	windows := map[string]map[string]float64{}
+	for _, window := range bcacheWindows {
+		values := map[string]float64{}
+		for _, f := range bcacheWindowFields {
+			v, err := readBcacheFile(path.Join(dir, "stats_"+window, f.file), f.parse)
+			if err != nil {
+				return nil, err
+			}
+			values[f.file] = v
+		}
+		windows[window] = values
+	}
+	return windows, nil
+}
+
+func readBcacheFile(name string, parse func(string) (float64, error)) (float64, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := parse(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s: %s", name, err)
+	}
+	return v, nil
+}
+
+// Parses a value as printed by bch_hprint, e.g. 1.2M, into a number.
+func parseBcacheValue(value string) (float64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("empty value")
+	}
+	var multiplier float64 = 1
+	if i := strings.IndexByte(bcacheUnits, value[len(value)-1]); i >= 0 {
+		multiplier = math.Pow(1024, float64(i+1))
+		value = value[:len(value)-1]
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return v * multiplier, nil
+}
+
+func parseBcachePercent(value string) (float64, error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return v / 100, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestBcache(t *testing.T) {
+	s, err := readBcacheStats("fixtures/sys/fs/bcache/deaddd54-c735-46d5-868e-f331c5fd7c74")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 25690112.0, s.btreeCacheSize; want != got {
+		t.Errorf("want btree cache size %f, got %f", want, got)
+	}
+
+	if want, got := 0.82, s.cacheAvailable; want != got {
+		t.Errorf("want cache available ratio %f, got %f", want, got)
+	}
+
+	if want, got := 0.71, s.windows["day"]["cache_hit_ratio"]; want != got {
+		t.Errorf("want daily hit ratio %f, got %f", want, got)
+	}
+
+	if want, got := 192179.0, s.windows["total"]["cache_misses"]; want != got {
+		t.Errorf("want %f total misses, got %f", want, got)
+	}
+
+	if want, got := 1, len(s.backingDevices); want != got {
+		t.Fatalf("want %d backing devices, got %d", want, got)
+	}
+
+	b := s.backingDevices[0]
+	if want, got := "sdb", b.name; want != got {
+		t.Errorf("want backing device %s, got %s", want, got)
+	}
+
+	if want, got := 3670016.0, b.dirtyData; want != got {
+		t.Errorf("want dirty data %f, got %f", want, got)
+	}
+
+	if want, got := 524288.0, b.writebackRate; want != got {
+		t.Errorf("want writeback rate %f, got %f", want, got)
+	}
+
+	if want, got := 1572864.0, b.windows["hour"]["bypassed"]; want != got {
+		t.Errorf("want %f bytes bypassed within the hour, got %f", want, got)
+	}
+}
+
+func TestParseBcacheValue(t *testing.T) {
+	for value, want := range map[string]float64{
+		"0":      0,
+		"512":    512,
+		"4.0k":   4096,
+		"1.5M":   1572864,
+		"2.0G":   2147483648,
+		"-12.0k": -12288,
+	} {
+		got, err := parseBcacheValue(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != got {
+			t.Errorf("want %f for %s, got %f", want, value, got)
+		}
+	}
+
+	for _, value := range []string{"", "k", "1.2X"} {
+		if _, err := parseBcacheValue(value); err == nil {
+			t.Errorf("want error for %q", value)
+		}
+	}
+}
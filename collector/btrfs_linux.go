@@ -0,0 +1,256 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nobtrfs
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	btrfsSubsystem = "btrfs"
+	// Block device sizes in sysfs are in 512 byte sectors, independent of
+	// the sector size of the device.
+	btrfsSectorSize = 512
+)
+
+var (
+	btrfsBlockGroupTypes = []string{"data", "metadata", "system"}
+	btrfsLabelNames      = []string{"uuid", "label"}
+)
+
+type btrfsAllocation struct {
+	totalBytes, bytesUsed, diskTotal, diskUsed float64
+}
+
+type btrfsStats struct {
+	uuid, label       string
+	allocation        map[string]btrfsAllocation    // block group type -> allocation
+	globalRsvSize     float64                       // bytes
+	globalRsvReserved float64                       // bytes
+	deviceSizes       map[string]float64            // device -> bytes
+	deviceErrors      map[string]map[string]float64 // device id -> error type -> count
+}
+
+type btrfsCollector struct {
+	allocatedDesc, usedDesc, diskAllocatedDesc, diskUsedDesc,
+	globalRsvSizeDesc, globalRsvReservedDesc,
+	deviceSizeDesc, deviceErrorsDesc *prometheus.Desc
+}
+
+func init() {
+	Factories["btrfs"] = NewBtrfsCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// btrfs allocation statistics.
+func NewBtrfsCollector() (Collector, error) {
+	blockGroupLabelNames := append(btrfsLabelNames, "block_group_type")
+	return &btrfsCollector{
+		allocatedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, btrfsSubsystem, "allocated_bytes"),
+			"Bytes allocated to chunks of the block group type.",
+			blockGroupLabelNames, nil,
+		),
+		usedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, btrfsSubsystem, "used_bytes"),
+			"Bytes used in chunks of the block group type.",
+			blockGroupLabelNames, nil,
+		),
+		diskAllocatedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, btrfsSubsystem, "disk_allocated_bytes"),
+			"Bytes allocated on disk to chunks of the block group type, including redundant copies.",
+			blockGroupLabelNames, nil,
+		),
+		diskUsedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, btrfsSubsystem, "disk_used_bytes"),
+			"Bytes used on disk in chunks of the block group type, including redundant copies.",
+			blockGroupLabelNames, nil,
+		),
+		globalRsvSizeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, btrfsSubsystem, "global_rsv_size_bytes"),
+			"Size of the global reserve.",
+			btrfsLabelNames, nil,
+		),
+		globalRsvReservedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, btrfsSubsystem, "global_rsv_reserved_bytes"),
+			"Bytes reserved in the global reserve.",
+			btrfsLabelNames, nil,
+		),
+		deviceSizeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, btrfsSubsystem, "device_size_bytes"),
+			"Size of a device of the filesystem.",
+			append(btrfsLabelNames, "device"), nil,
+		),
+		deviceErrorsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, btrfsSubsystem, "device_errors_total"),
+			"Errors of a device of the filesystem by type.",
+			append(btrfsLabelNames, "device_id", "type"), nil,
+		),
+	}, nil
+}
+
+func (c *btrfsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	stats, err := getBtrfsStats()
+	if err != nil {
+		return fmt.Errorf("couldn't get btrfs stats: %s", err)
+	}
+	for _, s := range stats {
+		for typ, a := range s.allocation {
+			ch <- prometheus.MustNewConstMetric(c.allocatedDesc, prometheus.GaugeValue, a.totalBytes, s.uuid, s.label, typ)
+			ch <- prometheus.MustNewConstMetric(c.usedDesc, prometheus.GaugeValue, a.bytesUsed, s.uuid, s.label, typ)
+			ch <- prometheus.MustNewConstMetric(c.diskAllocatedDesc, prometheus.GaugeValue, a.diskTotal, s.uuid, s.label, typ)
+			ch <- prometheus.MustNewConstMetric(c.diskUsedDesc, prometheus.GaugeValue, a.diskUsed, s.uuid, s.label, typ)
+		}
+		ch <- prometheus.MustNewConstMetric(c.globalRsvSizeDesc, prometheus.GaugeValue, s.globalRsvSize, s.uuid, s.label)
+		ch <- prometheus.MustNewConstMetric(c.globalRsvReservedDesc, prometheus.GaugeValue, s.globalRsvReserved, s.uuid, s.label)
+		for dev, size := range s.deviceSizes {
+			ch <- prometheus.MustNewConstMetric(c.deviceSizeDesc, prometheus.GaugeValue, size, s.uuid, s.label, dev)
+		}
+		for id, errs := range s.deviceErrors {
+			for typ, v := range errs {
+				ch <- prometheus.MustNewConstMetric(c.deviceErrorsDesc, prometheus.CounterValue, v, s.uuid, s.label, id, typ)
+			}
+		}
+	}
+	return nil
+}
+
+func getBtrfsStats() ([]btrfsStats, error) {
+	// Besides one directory per filesystem UUID, /sys/fs/btrfs also
+	// contains the features directory.
+	filesystems, err := filepath.Glob(sysFilePath("fs/btrfs/*-*-*-*-*"))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]btrfsStats, 0, len(filesystems))
+	for _, fs := range filesystems {
+		s, err := readBtrfsStats(fs)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+func readBtrfsStats(fs string) (btrfsStats, error) {
+	s := btrfsStats{
+		uuid:         path.Base(fs),
+		allocation:   map[string]btrfsAllocation{},
+		deviceSizes:  map[string]float64{},
+		deviceErrors: map[string]map[string]float64{},
+	}
+
+	label, err := ioutil.ReadFile(path.Join(fs, "label"))
+	if err != nil {
+		return s, err
+	}
+	s.label = strings.TrimSpace(string(label))
+
+	for _, typ := range btrfsBlockGroupTypes {
+		var (
+			a   btrfsAllocation
+			dir = path.Join(fs, "allocation", typ)
+		)
+		for file, value := range map[string]*float64{
+			"total_bytes": &a.totalBytes,
+			"bytes_used":  &a.bytesUsed,
+			"disk_total":  &a.diskTotal,
+			"disk_used":   &a.diskUsed,
+		} {
+			v, err := readUintFromFile(path.Join(dir, file))
+			if err != nil {
+				return s, err
+			}
+			*value = float64(v)
+		}
+		s.allocation[typ] = a
+	}
+
+	for file, value := range map[string]*float64{
+		"global_rsv_size":     &s.globalRsvSize,
+		"global_rsv_reserved": &s.globalRsvReserved,
+	} {
+		v, err := readUintFromFile(path.Join(fs, "allocation", file))
+		if err != nil {
+			return s, err
+		}
+		*value = float64(v)
+	}
+
+	devices, err := ioutil.ReadDir(path.Join(fs, "devices"))
+	if err != nil {
+		return s, err
+	}
+	for _, dev := range devices {
+		size, err := readUintFromFile(path.Join(fs, "devices", dev.Name(), "size"))
+		if err != nil {
+			return s, err
+		}
+		s.deviceSizes[dev.Name()] = float64(size * btrfsSectorSize)
+	}
+
+	// Error statistics per device id were added in Linux 5.14.
+	errorStats, err := filepath.Glob(path.Join(fs, "devinfo", "*", "error_stats"))
+	if err != nil {
+		return s, err
+	}
+	for _, e := range errorStats {
+		file, err := os.Open(e)
+		if err != nil {
+			return s, err
+		}
+		errs, err := parseBtrfsErrorStats(file)
+		file.Close()
+		if err != nil {
+			return s, err
+		}
+		s.deviceErrors[path.Base(path.Dir(e))] = errs
+	}
+
+	return s, nil
+}
+
+func parseBtrfsErrorStats(r io.Reader) (map[string]float64, error) {
+	var (
+		errs    = map[string]float64{}
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line in error_stats: %s", scanner.Text())
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s in error_stats: %s", parts[1], err)
+		}
+		errs[strings.TrimSuffix(parts[0], "_errs")] = v
+	}
+	return errs, scanner.Err()
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestBtrfs(t *testing.T) {
+	s, err := readBtrfsStats("fixtures/sys/fs/btrfs/0abb23a9-9aa0-4fa5-a2d2-bbfc5e7b1a37")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "fixture", s.label; want != got {
+		t.Errorf("want label %s, got %s", want, got)
+	}
+
+	if want, got := (btrfsAllocation{268435456, 933888, 536870912, 1867776}), s.allocation["metadata"]; want != got {
+		t.Errorf("want metadata allocation %v, got %v", want, got)
+	}
+
+	if want, got := 16777216.0, s.globalRsvSize; want != got {
+		t.Errorf("want global reserve size %f, got %f", want, got)
+	}
+
+	if want, got := 10737418240.0, s.deviceSizes["loop25"]; want != got {
+		t.Errorf("want device size %f, got %f", want, got)
+	}
+
+	s, err = readBtrfsStats("fixtures/sys/fs/btrfs/7f07c59f-6136-449c-ab87-e1cf2328731b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "", s.label; want != got {
+		t.Errorf("want empty label, got %s", got)
+	}
+
+	if want, got := 2, len(s.deviceSizes); want != got {
+		t.Errorf("want %d devices, got %d", want, got)
+	}
+
+	if want, got := 2.0, s.deviceErrors["1"]["read"]; want != got {
+		t.Errorf("want %f read errors on device 1, got %f", want, got)
+	}
+
+	if want, got := 12.0, s.deviceErrors["2"]["write"]; want != got {
+		t.Errorf("want %f write errors on device 2, got %f", want, got)
+	}
+}
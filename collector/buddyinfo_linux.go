@@ -0,0 +1,112 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nobuddyinfo
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	buddyInfoSubsystem = "buddyinfo"
+)
+
+// Free blocks of a zone of a NUMA node, by order.
+type buddyInfoZone struct {
+	node, zone string
+	blocks     []float64
+}
+
+type buddyInfoCollector struct {
+	desc *prometheus.Desc
+}
+
+func init() {
+	Factories["buddyinfo"] = NewBuddyInfoCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// the free memory blocks of the buddy allocator.
+func NewBuddyInfoCollector() (Collector, error) {
+	return &buddyInfoCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, buddyInfoSubsystem, "blocks"),
+			"Number of free blocks of 2^order pages in the zone of the NUMA node.",
+			[]string{"node", "zone", "order"}, nil,
+		),
+	}, nil
+}
+
+func (c *buddyInfoCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	zones, err := getBuddyInfo()
+	if err != nil {
+		return fmt.Errorf("couldn't get buddyinfo: %s", err)
+	}
+	for _, z := range zones {
+		for order, v := range z.blocks {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, v, z.node, z.zone, strconv.Itoa(order))
+		}
+	}
+	return nil
+}
+
+func getBuddyInfo() ([]buddyInfoZone, error) {
+	file, err := os.Open(procFilePath("buddyinfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseBuddyInfo(file)
+}
+
+// Parses lines like "Node 0, zone   Normal   4381   1093    185 ...".
+func parseBuddyInfo(r io.Reader) ([]buddyInfoZone, error) {
+	var (
+		zones   []buddyInfoZone
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) < 4 || parts[0] != "Node" || parts[2] != "zone" {
+			return nil, fmt.Errorf("invalid line in buddyinfo: %s", scanner.Text())
+		}
+
+		z := buddyInfoZone{
+			node: strings.TrimSuffix(parts[1], ","),
+			zone: parts[3],
+		}
+		for _, p := range parts[4:] {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s in buddyinfo: %s", p, err)
+			}
+			z.blocks = append(z.blocks, v)
+		}
+		zones = append(zones, z)
+	}
+	return zones, scanner.Err()
+}
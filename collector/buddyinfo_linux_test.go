@@ -0,0 +1,49 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuddyInfo(t *testing.T) {
+	file, err := os.Open("fixtures/proc/buddyinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zones, err := parseBuddyInfo(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 4, len(zones); want != got {
+		t.Fatalf("want %d zones, got %d", want, got)
+	}
+
+	if want, got := "DMA32", zones[1].zone; want != got {
+		t.Errorf("want zone %s, got %s", want, got)
+	}
+
+	if want, got := 3.0, zones[3].blocks[7]; zones[3].node != "1" || want != got {
+		t.Errorf("want %f free blocks of order 7 on node 1, got %f", want, got)
+	}
+
+	if _, err := parseBuddyInfo(strings.NewReader("Node 0, zone Normal 1 x\n")); err == nil {
+		t.Error("want error for invalid value")
+	}
+}
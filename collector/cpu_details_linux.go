@@ -0,0 +1,295 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocpu_details
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	cpuSubsystem = "cpu"
+)
+
+var (
+	// Files of the cpufreq directory of a CPU, all in kHz. cpuinfo_cur_freq
+	// is only readable by root.
+	cpuFreqFields = []struct {
+		file, name, help string
+	}{
+		{"cpuinfo_cur_freq", "frequency_hertz", "Current CPU frequency as reported by the hardware."},
+		{"cpuinfo_min_freq", "frequency_min_hertz", "Minimum CPU frequency supported by the hardware."},
+		{"cpuinfo_max_freq", "frequency_max_hertz", "Maximum CPU frequency supported by the hardware."},
+		{"scaling_cur_freq", "scaling_frequency_hertz", "Current CPU frequency as determined by the governor."},
+		{"scaling_min_freq", "scaling_frequency_min_hertz", "Minimum CPU frequency the governor may select."},
+		{"scaling_max_freq", "scaling_frequency_max_hertz", "Maximum CPU frequency the governor may select."},
+	}
+)
+
+type cpuDetails struct {
+	name               string
+	packageID, coreID  string
+	frequencies        map[string]float64 // file -> hertz
+	governor           string
+	availableGovernors []string
+	hasThrottles       bool
+	coreThrottles      float64
+	packageThrottles   float64
+}
+
+type cpuDetailsCollector struct {
+	frequencyDescs []*prometheus.Desc
+	governorDesc, coreThrottlesDesc, packageThrottlesDesc,
+	topologyDesc, vulnerabilityDesc *prometheus.Desc
+}
+
+func init() {
+	Factories["cpu_details"] = NewCPUDetailsCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// CPU frequency, thermal throttling, topology and vulnerability details.
+func NewCPUDetailsCollector() (Collector, error) {
+	frequencyDescs := make([]*prometheus.Desc, 0, len(cpuFreqFields))
+	for _, f := range cpuFreqFields {
+		frequencyDescs = append(frequencyDescs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuSubsystem, f.name),
+			f.help, []string{"cpu"}, nil,
+		))
+	}
+	return &cpuDetailsCollector{
+		frequencyDescs: frequencyDescs,
+		governorDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuSubsystem, "scaling_governor"),
+			"Indicates the cpufreq governor of the CPU.",
+			[]string{"cpu", "governor"}, nil,
+		),
+		coreThrottlesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuSubsystem, "core_throttles_total"),
+			"Number of times the core has been throttled due to high temperature.",
+			[]string{"package", "core"}, nil,
+		),
+		packageThrottlesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuSubsystem, "package_throttles_total"),
+			"Number of times the package has been throttled due to high temperature.",
+			[]string{"package"}, nil,
+		),
+		topologyDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuSubsystem, "topology_info"),
+			"Physical package and core of the CPU.",
+			[]string{"cpu", "package", "core"}, nil,
+		),
+		vulnerabilityDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuSubsystem, "vulnerabilities_info"),
+			"State of the CPU vulnerability as reported by the kernel.",
+			[]string{"codename", "state", "description"}, nil,
+		),
+	}, nil
+}
+
+func (c *cpuDetailsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cpus, err := getCPUDetails()
+	if err != nil {
+		return fmt.Errorf("couldn't get CPU details: %s", err)
+	}
+
+	// Siblings of a core share its throttle count, and all cores of a
+	// package its package throttle count.
+	coreThrottles := map[[2]string]float64{}
+	packageThrottles := map[string]float64{}
+	for _, cpu := range cpus {
+		for i, f := range cpuFreqFields {
+			if v, ok := cpu.frequencies[f.file]; ok {
+				ch <- prometheus.MustNewConstMetric(c.frequencyDescs[i], prometheus.GaugeValue, v, cpu.name)
+			}
+		}
+
+		if cpu.governor != "" {
+			governors := cpu.availableGovernors
+			if len(governors) == 0 {
+				governors = []string{cpu.governor}
+			}
+			for _, g := range governors {
+				var v float64
+				if g == cpu.governor {
+					v = 1
+				}
+				ch <- prometheus.MustNewConstMetric(c.governorDesc, prometheus.GaugeValue, v, cpu.name, g)
+			}
+		}
+
+		if cpu.packageID == "" {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.topologyDesc, prometheus.GaugeValue, 1, cpu.name, cpu.packageID, cpu.coreID)
+		if cpu.hasThrottles {
+			coreThrottles[[2]string{cpu.packageID, cpu.coreID}] = cpu.coreThrottles
+			packageThrottles[cpu.packageID] = cpu.packageThrottles
+		}
+	}
+	for core, v := range coreThrottles {
+		ch <- prometheus.MustNewConstMetric(c.coreThrottlesDesc, prometheus.CounterValue, v, core[0], core[1])
+	}
+	for pkg, v := range packageThrottles {
+		ch <- prometheus.MustNewConstMetric(c.packageThrottlesDesc, prometheus.CounterValue, v, pkg)
+	}
+
+	vulnerabilities, err := getCPUVulnerabilities()
+	if err != nil {
+		return fmt.Errorf("couldn't get CPU vulnerabilities: %s", err)
+	}
+	for codename, description := range vulnerabilities {
+		ch <- prometheus.MustNewConstMetric(c.vulnerabilityDesc, prometheus.GaugeValue, 1,
+			codename, cpuVulnerabilityState(description), description)
+	}
+	return nil
+}
+
+func getCPUDetails() ([]cpuDetails, error) {
+	dirs, err := filepath.Glob(sysFilePath("devices/system/cpu/cpu[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+
+	cpus := make([]cpuDetails, 0, len(dirs))
+	for _, dir := range dirs {
+		cpu, err := readCPUDetails(dir)
+		if err != nil {
+			return nil, err
+		}
+		cpus = append(cpus, cpu)
+	}
+	return cpus, nil
+}
+
+// Reads the details of the CPU in dir. All of them are optional, as they
+// depend on the cpufreq driver, the architecture and the kernel version.
+func readCPUDetails(dir string) (cpuDetails, error) {
+	cpu := cpuDetails{
+		name:        path.Base(dir),
+		frequencies: map[string]float64{},
+	}
+
+	for _, f := range cpuFreqFields {
+		v, ok, err := readCPUDetailsFile(path.Join(dir, "cpufreq", f.file))
+		if err != nil {
+			return cpu, err
+		}
+		if !ok {
+			continue
+		}
+		khz, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cpu, fmt.Errorf("invalid %s of %s: %s", f.file, cpu.name, err)
+		}
+		cpu.frequencies[f.file] = khz * 1000
+	}
+
+	governor, _, err := readCPUDetailsFile(path.Join(dir, "cpufreq", "scaling_governor"))
+	if err != nil {
+		return cpu, err
+	}
+	cpu.governor = governor
+	available, _, err := readCPUDetailsFile(path.Join(dir, "cpufreq", "scaling_available_governors"))
+	if err != nil {
+		return cpu, err
+	}
+	cpu.availableGovernors = strings.Fields(available)
+
+	packageID, _, err := readCPUDetailsFile(path.Join(dir, "topology", "physical_package_id"))
+	if err != nil {
+		return cpu, err
+	}
+	coreID, _, err := readCPUDetailsFile(path.Join(dir, "topology", "core_id"))
+	if err != nil {
+		return cpu, err
+	}
+	cpu.packageID, cpu.coreID = packageID, coreID
+
+	for file, value := range map[string]*float64{
+		"core_throttle_count":    &cpu.coreThrottles,
+		"package_throttle_count": &cpu.packageThrottles,
+	} {
+		v, ok, err := readCPUDetailsFile(path.Join(dir, "thermal_throttle", file))
+		if err != nil {
+			return cpu, err
+		}
+		if !ok {
+			continue
+		}
+		*value, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cpu, fmt.Errorf("invalid %s of %s: %s", file, cpu.name, err)
+		}
+		cpu.hasThrottles = true
+	}
+	return cpu, nil
+}
+
+// Returns the description of each CPU vulnerability the kernel knows about.
+func getCPUVulnerabilities() (map[string]string, error) {
+	files, err := filepath.Glob(sysFilePath("devices/system/cpu/vulnerabilities/*"))
+	if err != nil {
+		return nil, err
+	}
+
+	vulnerabilities := map[string]string{}
+	for _, f := range files {
+		v, ok, err := readCPUDetailsFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			vulnerabilities[path.Base(f)] = v
+		}
+	}
+	return vulnerabilities, nil
+}
+
+// Maps the description of a CPU vulnerability, e.g. "Mitigation: PTI", to
+// its state.
+func cpuVulnerabilityState(description string) string {
+	switch {
+	case description == "Not affected":
+		return "not_affected"
+	case strings.HasPrefix(description, "Vulnerable"):
+		return "vulnerable"
+	case strings.Contains(description, "Mitigation"):
+		return "mitigation"
+	default:
+		return "unknown"
+	}
+}
+
+// Reads a sysfs file of a CPU, returning false if it doesn't exist or isn't
+// readable.
+func readCPUDetailsFile(name string) (string, bool, error) {
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) || os.IsPermission(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
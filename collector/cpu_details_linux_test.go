@@ -0,0 +1,72 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestCPUDetails(t *testing.T) {
+	cpu, err := readCPUDetails("fixtures/sys/devices/system/cpu/cpu0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1700000000.0, cpu.frequencies["cpuinfo_cur_freq"]; want != got {
+		t.Errorf("want hardware frequency %f, got %f", want, got)
+	}
+
+	if want, got := 2800000000.0, cpu.frequencies["scaling_max_freq"]; want != got {
+		t.Errorf("want scaling max frequency %f, got %f", want, got)
+	}
+
+	if want, got := "performance", cpu.governor; want != got {
+		t.Errorf("want governor %s, got %s", want, got)
+	}
+
+	if want, got := 2, len(cpu.availableGovernors); want != got {
+		t.Errorf("want %d available governors, got %d", want, got)
+	}
+
+	if want, got := 5.0, cpu.coreThrottles; !cpu.hasThrottles || want != got {
+		t.Errorf("want %f core throttles, got %f", want, got)
+	}
+
+	cpu, err = readCPUDetails("fixtures/sys/devices/system/cpu/cpu3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 0, len(cpu.frequencies); want != got {
+		t.Errorf("want no frequencies for cpu without cpufreq, got %v", cpu.frequencies)
+	}
+
+	if want, got := "1", cpu.coreID; want != got {
+		t.Errorf("want core %s, got %s", want, got)
+	}
+}
+
+func TestCPUVulnerabilities(t *testing.T) {
+	for description, want := range map[string]string{
+		"Not affected":                      "not_affected",
+		"Mitigation: PTI":                   "mitigation",
+		"KVM: Mitigation: Split huge pages": "mitigation",
+		"Vulnerable: Clear CPU buffers attempted, no microcode; SMT vulnerable": "vulnerable",
+		"Unknown: Dependent on hypervisor status":                               "unknown",
+	} {
+		if got := cpuVulnerabilityState(description); want != got {
+			t.Errorf("want state %s for %q, got %s", want, description, got)
+		}
+	}
+}
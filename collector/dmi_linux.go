@@ -0,0 +1,125 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodmi
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var (
+	dmiRootFields = flag.Bool("collector.dmi.root-fields", false, "Also export DMI fields only readable by root, like product_serial.")
+
+	// Files of /sys/class/dmi/id and the labels they're exported as.
+	dmiFields = []struct {
+		file, label string
+		root        bool
+	}{
+		{"bios_vendor", "bios_vendor", false},
+		{"bios_version", "bios_version", false},
+		{"bios_date", "bios_date", false},
+		{"board_vendor", "board_vendor", false},
+		{"board_name", "board_name", false},
+		{"product_name", "product_name", false},
+		{"product_version", "product_version", false},
+		{"chassis_type", "chassis_type", false},
+		{"sys_vendor", "system_vendor", false},
+		{"product_serial", "product_serial", true},
+	}
+)
+
+type dmiCollector struct {
+	files []string
+	desc  *prometheus.Desc
+}
+
+func init() {
+	Factories["dmi"] = NewDMICollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// the DMI information of the system.
+func NewDMICollector() (Collector, error) {
+	var files, labels []string
+	for _, f := range dmiFields {
+		if f.root && !*dmiRootFields {
+			continue
+		}
+		files = append(files, f.file)
+		labels = append(labels, f.label)
+	}
+	return &dmiCollector{
+		files: files,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "dmi", "info"),
+			"Labeled DMI information as provided by the firmware.",
+			labels, nil,
+		),
+	}, nil
+}
+
+func (c *dmiCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	dir := sysFilePath("class/dmi/id")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		log.Debugf("Not collecting DMI information, %s does not exist", dir)
+		return nil
+	}
+
+	values, err := readDMIFields(dir, c.files)
+	if err != nil {
+		return fmt.Errorf("couldn't get DMI information: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1, values...)
+	return nil
+}
+
+// Reads the files in dir, leaving the values of the ones that don't exist
+// or aren't readable empty.
+func readDMIFields(dir string, files []string) ([]string, error) {
+	values := make([]string, 0, len(files))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(path.Join(dir, file))
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			values = append(values, "")
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, sanitizeDMIValue(string(data)))
+	}
+	return values, nil
+}
+
+// Firmware fills DMI strings with whatever it likes, including padding,
+// control characters and invalid UTF-8, none of which belongs in a label.
+func sanitizeDMIValue(value string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, value))
+}
@@ -0,0 +1,36 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestDMI(t *testing.T) {
+	values, err := readDMIFields("fixtures/sys/class/dmi/id",
+		[]string{"bios_version", "product_version", "sys_vendor", "product_uuid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{"N24ET56W (1.31 )", "ThinkPad T480", "LENOVO", ""} {
+		if got := values[i]; want != got {
+			t.Errorf("want value %q, got %q", want, got)
+		}
+	}
+
+	if want, got := "Default string", sanitizeDMIValue("\tDefault string\xff\x00\n"); want != got {
+		t.Errorf("want sanitized value %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noedac
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	edacSubsystem = "edac"
+)
+
+var (
+	edacControllerRE = regexp.MustCompile(`^mc([0-9]+)$`)
+	edacCsrowRE      = regexp.MustCompile(`^csrow([0-9]+)$`)
+	edacChannelRE    = regexp.MustCompile(`^ch([0-9]+)_ce_count$`)
+)
+
+// Error counts of a memory controller, chip select row or DIMM.
+type edacCounts struct {
+	correctable, uncorrectable float64
+}
+
+type edacChannel struct {
+	channel, label string
+	correctable    float64
+}
+
+type edacCsrow struct {
+	csrow string
+	edacCounts
+	channels []edacChannel
+}
+
+type edacDimm struct {
+	dimm, label, location string
+	edacCounts
+}
+
+type edacController struct {
+	controller string
+	edacCounts
+	noinfo edacCounts // errors that couldn't be attributed to a csrow
+	csrows []edacCsrow
+	dimms  []edacDimm
+}
+
+type edacCollector struct {
+	correctableDesc, uncorrectableDesc,
+	noinfoCorrectableDesc, noinfoUncorrectableDesc,
+	csrowCorrectableDesc, csrowUncorrectableDesc, channelCorrectableDesc,
+	dimmCorrectableDesc, dimmUncorrectableDesc *prometheus.Desc
+}
+
+func init() {
+	Factories["edac"] = NewEdacCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// memory error counts of the EDAC subsystem.
+func NewEdacCollector() (Collector, error) {
+	var (
+		controllerLabels = []string{"controller"}
+		csrowLabels      = []string{"controller", "csrow"}
+		dimmLabels       = []string{"controller", "dimm", "label", "location"}
+	)
+	return &edacCollector{
+		correctableDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, edacSubsystem, "correctable_errors_total"),
+			"Total correctable memory errors of the memory controller.",
+			controllerLabels, nil,
+		),
+		uncorrectableDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, edacSubsystem, "uncorrectable_errors_total"),
+			"Total uncorrectable memory errors of the memory controller.",
+			controllerLabels, nil,
+		),
+		noinfoCorrectableDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, edacSubsystem, "noinfo_correctable_errors_total"),
+			"Total correctable memory errors of the memory controller without information about their location.",
+			controllerLabels, nil,
+		),
+		noinfoUncorrectableDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, edacSubsystem, "noinfo_uncorrectable_errors_total"),
+			"Total uncorrectable memory errors of the memory controller without information about their location.",
+			controllerLabels, nil,
+		),
+		csrowCorrectableDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, edacSubsystem, "csrow_correctable_errors_total"),
+			"Total correctable memory errors of the chip select row.",
+			csrowLabels, nil,
+		),
+		csrowUncorrectableDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, edacSubsystem, "csrow_uncorrectable_errors_total"),
+			"Total uncorrectable memory errors of the chip select row.",
+			csrowLabels, nil,
+		),
+		channelCorrectableDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, edacSubsystem, "csrow_channel_correctable_errors_total"),
+			"Total correctable memory errors of the channel of the chip select row.",
+			[]string{"controller", "csrow", "channel", "label"}, nil,
+		),
+		dimmCorrectableDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, edacSubsystem, "dimm_correctable_errors_total"),
+			"Total correctable memory errors of the DIMM.",
+			dimmLabels, nil,
+		),
+		dimmUncorrectableDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, edacSubsystem, "dimm_uncorrectable_errors_total"),
+			"Total uncorrectable memory errors of the DIMM.",
+			dimmLabels, nil,
+		),
+	}, nil
+}
+
+func (c *edacCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	controllers, err := getEdacControllers()
+	if err != nil {
+		return fmt.Errorf("couldn't get EDAC memory controllers: %s", err)
+	}
+
+	for _, mc := range controllers {
+		ch <- prometheus.MustNewConstMetric(c.correctableDesc, prometheus.CounterValue, mc.correctable, mc.controller)
+		ch <- prometheus.MustNewConstMetric(c.uncorrectableDesc, prometheus.CounterValue, mc.uncorrectable, mc.controller)
+		ch <- prometheus.MustNewConstMetric(c.noinfoCorrectableDesc, prometheus.CounterValue, mc.noinfo.correctable, mc.controller)
+		ch <- prometheus.MustNewConstMetric(c.noinfoUncorrectableDesc, prometheus.CounterValue, mc.noinfo.uncorrectable, mc.controller)
+
+		for _, row := range mc.csrows {
+			ch <- prometheus.MustNewConstMetric(c.csrowCorrectableDesc, prometheus.CounterValue, row.correctable, mc.controller, row.csrow)
+			ch <- prometheus.MustNewConstMetric(c.csrowUncorrectableDesc, prometheus.CounterValue, row.uncorrectable, mc.controller, row.csrow)
+			for _, channel := range row.channels {
+				ch <- prometheus.MustNewConstMetric(c.channelCorrectableDesc, prometheus.CounterValue, channel.correctable,
+					mc.controller, row.csrow, channel.channel, channel.label)
+			}
+		}
+
+		for _, dimm := range mc.dimms {
+			ch <- prometheus.MustNewConstMetric(c.dimmCorrectableDesc, prometheus.CounterValue, dimm.correctable,
+				mc.controller, dimm.dimm, dimm.label, dimm.location)
+			ch <- prometheus.MustNewConstMetric(c.dimmUncorrectableDesc, prometheus.CounterValue, dimm.uncorrectable,
+				mc.controller, dimm.dimm, dimm.label, dimm.location)
+		}
+	}
+	return nil
+}
+
+func getEdacControllers() ([]edacController, error) {
+	dirs, err := filepath.Glob(sysFilePath("devices/system/edac/mc/mc[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+
+	controllers := make([]edacController, 0, len(dirs))
+	for _, dir := range dirs {
+		matches := edacControllerRE.FindStringSubmatch(path.Base(dir))
+		if matches == nil {
+			continue
+		}
+		mc, err := readEdacController(dir)
+		if err != nil {
+			return nil, err
+		}
+		mc.controller = matches[1]
+		controllers = append(controllers, mc)
+	}
+	return controllers, nil
+}
+
+// Reads the memory controller in dir. Depending on the driver and kernel
+// configuration, its errors are broken down by chip select rows (csrow<n>),
+// DIMMs (dimm<n>) or ranks (rank<n>), or not at all.
+func readEdacController(dir string) (edacController, error) {
+	var (
+		mc  edacController
+		err error
+	)
+	if mc.edacCounts, err = readEdacCounts(dir, "ce_count", "ue_count"); err != nil {
+		return mc, err
+	}
+	if mc.noinfo, err = readEdacCounts(dir, "ce_noinfo_count", "ue_noinfo_count"); err != nil {
+		return mc, err
+	}
+
+	rows, err := filepath.Glob(path.Join(dir, "csrow[0-9]*"))
+	if err != nil {
+		return mc, err
+	}
+	for _, rowDir := range rows {
+		matches := edacCsrowRE.FindStringSubmatch(path.Base(rowDir))
+		if matches == nil {
+			continue
+		}
+		row, err := readEdacCsrow(rowDir)
+		if err != nil {
+			return mc, err
+		}
+		row.csrow = matches[1]
+		mc.csrows = append(mc.csrows, row)
+	}
+
+	dimms, err := filepath.Glob(path.Join(dir, "dimm[0-9]*"))
+	if err != nil {
+		return mc, err
+	}
+	ranks, err := filepath.Glob(path.Join(dir, "rank[0-9]*"))
+	if err != nil {
+		return mc, err
+	}
+	for _, dimmDir := range append(dimms, ranks...) {
+		dimm := edacDimm{dimm: path.Base(dimmDir)}
+		if dimm.edacCounts, err = readEdacCounts(dimmDir, "dimm_ce_count", "dimm_ue_count"); err != nil {
+			return mc, err
+		}
+		if dimm.label, err = readEdacString(path.Join(dimmDir, "dimm_label")); err != nil {
+			return mc, err
+		}
+		if dimm.location, err = readEdacString(path.Join(dimmDir, "dimm_location")); err != nil {
+			return mc, err
+		}
+		mc.dimms = append(mc.dimms, dimm)
+	}
+	return mc, nil
+}
+
+func readEdacCsrow(dir string) (edacCsrow, error) {
+	var (
+		row edacCsrow
+		err error
+	)
+	if row.edacCounts, err = readEdacCounts(dir, "ce_count", "ue_count"); err != nil {
+		return row, err
+	}
+
+	files, err := filepath.Glob(path.Join(dir, "ch[0-9]*_ce_count"))
+	if err != nil {
+		return row, err
+	}
+	for _, file := range files {
+		matches := edacChannelRE.FindStringSubmatch(path.Base(file))
+		if matches == nil {
+			continue
+		}
+		v, err := readUintFromFile(file)
+		if err != nil {
+			return row, err
+		}
+		label, err := readEdacString(path.Join(dir, "ch"+matches[1]+"_dimm_label"))
+		if err != nil {
+			return row, err
+		}
+		row.channels = append(row.channels, edacChannel{
+			channel:     matches[1],
+			label:       label,
+			correctable: float64(v),
+		})
+	}
+	return row, nil
+}
+
+func readEdacCounts(dir, correctableFile, uncorrectableFile string) (edacCounts, error) {
+	var counts edacCounts
+	for file, value := range map[string]*float64{
+		correctableFile:   &counts.correctable,
+		uncorrectableFile: &counts.uncorrectable,
+	} {
+		v, err := readUintFromFile(path.Join(dir, file))
+		if err != nil {
+			return counts, err
+		}
+		*value = float64(v)
+	}
+	return counts, nil
+}
+
+// Reads a label or location, which drivers don't necessarily provide.
+func readEdacString(name string) (string, error) {
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
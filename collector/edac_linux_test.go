@@ -0,0 +1,63 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestEdac(t *testing.T) {
+	mc, err := readEdacController("fixtures/sys/devices/system/edac/mc/mc0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := (edacCounts{correctable: 3, uncorrectable: 1}), mc.edacCounts; want != got {
+		t.Errorf("want controller errors %v, got %v", want, got)
+	}
+
+	if want, got := 1.0, mc.noinfo.correctable; want != got {
+		t.Errorf("want %f correctable errors without info, got %f", want, got)
+	}
+
+	if want, got := 2, len(mc.csrows); want != got {
+		t.Fatalf("want %d csrows, got %d", want, got)
+	}
+
+	if want, got := (edacChannel{channel: "0", label: "CPU_SrcID#0_MC#0_Chan#0_DIMM#0", correctable: 2}), mc.csrows[0].channels[0]; want != got {
+		t.Errorf("want channel %v, got %v", want, got)
+	}
+
+	if want, got := 2, len(mc.dimms); want != got {
+		t.Fatalf("want %d dimms, got %d", want, got)
+	}
+
+	if want, got := "channel 0 slot 0", mc.dimms[0].location; want != got {
+		t.Errorf("want dimm location %q, got %q", want, got)
+	}
+
+	mc, err = readEdacController("fixtures/sys/devices/system/edac/mc/mc1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 0, len(mc.csrows); want != got {
+		t.Errorf("want no csrows, got %d", got)
+	}
+
+	if want, got := (edacDimm{dimm: "rank0", label: "mc#1csrow#0channel#0", location: "csrow 0 channel 0",
+		edacCounts: edacCounts{correctable: 5}}), mc.dimms[0]; want != got {
+		t.Errorf("want rank %v, got %v", want, got)
+	}
+}
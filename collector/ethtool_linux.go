@@ -0,0 +1,314 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noethtool
+
+package collector
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	ethtoolSubsystem = "ethtool"
+
+	// From linux/sockios.h and linux/ethtool.h.
+	siocEthtool     = 0x8946
+	ethtoolGDrvInfo = 0x03
+	ethtoolGStrings = 0x1b
+	ethtoolGStats   = 0x1d
+	ethSSStats      = 1
+	ethGStringLen   = 32
+
+	// Size of struct ethtool_drvinfo and the offset of its n_stats field.
+	ethtoolDrvInfoLen    = 196
+	ethtoolDrvInfoNStats = 180
+)
+
+var (
+	ethtoolIgnoredDevices = flag.String(
+		"collector.ethtool.ignored-devices", "^$",
+		"Regexp of net devices to ignore for ethtool collector.")
+	ethtoolInclude = flag.String(
+		"collector.ethtool.include", "",
+		"Regexp of ethtool stats to include. Empty includes all.")
+
+	ethtoolInvalidRE = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+)
+
+// Driver details reported by ETHTOOL_GDRVINFO.
+type ethtoolDriverInfo struct {
+	driver, version, firmwareVersion, busInfo string
+}
+
+// The ethtool requests of the collector, tests replace the ioctls with
+// canned stats.
+type ethtool interface {
+	DriverInfo(device string) (ethtoolDriverInfo, error)
+	Stats(device string) (map[string]uint64, error)
+}
+
+type ethtoolCollector struct {
+	ethtool               ethtool
+	ignoredDevicesPattern *regexp.Regexp
+	include               *regexp.Regexp
+	infoDesc              *prometheus.Desc
+	statDescs             map[string]*prometheus.Desc
+}
+
+func init() {
+	Factories["ethtool"] = NewEthtoolCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// the driver statistics of network devices as reported by ethtool -S.
+func NewEthtoolCollector() (Collector, error) {
+	e, err := newEthtoolIoctl()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open ethtool socket: %s", err)
+	}
+	return newEthtoolCollector(e)
+}
+
+func newEthtoolCollector(e ethtool) (*ethtoolCollector, error) {
+	pattern, err := regexp.Compile(*ethtoolIgnoredDevices)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ethtool ignored devices pattern: %s", err)
+	}
+	c := &ethtoolCollector{
+		ethtool:               e,
+		ignoredDevicesPattern: pattern,
+		infoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ethtoolSubsystem, "info"),
+			"Driver, firmware and bus of the network device.",
+			[]string{"device", "driver", "version", "firmware_version", "bus_info"}, nil,
+		),
+		statDescs: map[string]*prometheus.Desc{},
+	}
+	if *ethtoolInclude != "" {
+		re, err := regexp.Compile(*ethtoolInclude)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ethtool include pattern: %s", err)
+		}
+		c.include = re
+	}
+	return c, nil
+}
+
+func (c *ethtoolCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	files, err := ioutil.ReadDir(sysFilePath("class/net"))
+	if err != nil {
+		return fmt.Errorf("couldn't get network devices: %s", err)
+	}
+
+	for _, f := range files {
+		dev := f.Name()
+		// Skip files like bonding_masters.
+		if info, err := os.Stat(sysFilePath(path.Join("class/net", dev))); err != nil || !info.IsDir() {
+			continue
+		}
+		if c.ignoredDevicesPattern.MatchString(dev) {
+			log.Debugf("Ignoring device: %s", dev)
+			continue
+		}
+
+		info, err := c.ethtool.DriverInfo(dev)
+		if ethtoolUnsupported(err) {
+			log.Debugf("Ignoring device %s without ethtool support: %s", dev, err)
+			continue
+		}
+		// Drivers may fail with e.g. EBUSY during a reset.
+		if err != nil {
+			log.Errorf("Couldn't get driver info of %s: %s", dev, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1,
+			dev, info.driver, info.version, info.firmwareVersion, info.busInfo)
+
+		stats, err := c.ethtool.Stats(dev)
+		if ethtoolUnsupported(err) {
+			log.Debugf("Ignoring stats of device %s: %s", dev, err)
+			continue
+		}
+		if err != nil {
+			log.Errorf("Couldn't get ethtool stats of %s: %s", dev, err)
+			continue
+		}
+		c.updateStats(ch, dev, stats)
+	}
+	return nil
+}
+
+func (c *ethtoolCollector) updateStats(ch chan<- prometheus.Metric, dev string, stats map[string]uint64) {
+	keys := make([]string, 0, len(stats))
+	for key := range stats {
+		if c.include != nil && !c.include.MatchString(key) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Drivers are free to name their stats, e.g. rx-errors and rx_errors
+	// end up with the same name.
+	names := map[string]bool{"info": true}
+	for _, key := range keys {
+		name := ethtoolInvalidRE.ReplaceAllString(key, "_")
+		if names[name] {
+			log.Debugf("Ignoring duplicate ethtool stat %s of device %s", key, dev)
+			continue
+		}
+		names[name] = true
+
+		desc, ok := c.statDescs[name]
+		if !ok {
+			desc = prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, ethtoolSubsystem, name),
+				fmt.Sprintf("Network device driver statistic %s.", key),
+				[]string{"device"}, nil,
+			)
+			c.statDescs[name] = desc
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.UntypedValue, float64(stats[key]), dev)
+	}
+}
+
+// Virtual devices like lo don't implement ethtool, and devices might have
+// vanished since listing them.
+func ethtoolUnsupported(err error) bool {
+	return err == syscall.EOPNOTSUPP || err == syscall.ENODEV
+}
+
+// The ifreq of SIOCETHTOOL, with ifr_data pointing to the ethtool command.
+// The union is as large as struct ifmap, two longs followed by 5 bytes.
+type ethtoolIfreq struct {
+	name [syscall.IFNAMSIZ]byte
+	data unsafe.Pointer
+	_    [unsafe.Sizeof(uintptr(0)) + 8]byte
+}
+
+// Sends ethtool requests with the SIOCETHTOOL ioctl on a socket.
+type ethtoolIoctl struct {
+	fd int
+	// Runs the ethtool command in data, which starts with the command
+	// number and is updated with the response. Replaced in tests.
+	ioctl func(device string, data []byte) error
+}
+
+func newEthtoolIoctl() (*ethtoolIoctl, error) {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	e := &ethtoolIoctl{fd: fd}
+	e.ioctl = e.siocEthtool
+	return e, nil
+}
+
+func (e *ethtoolIoctl) siocEthtool(device string, data []byte) error {
+	if len(device) >= syscall.IFNAMSIZ {
+		return fmt.Errorf("invalid device name %s", device)
+	}
+	ifr := ethtoolIfreq{data: unsafe.Pointer(&data[0])}
+	copy(ifr.name[:], device)
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(e.fd), siocEthtool, uintptr(unsafe.Pointer(&ifr)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (e *ethtoolIoctl) driverInfo(device string) ([]byte, error) {
+	data := make([]byte, ethtoolDrvInfoLen)
+	nativeEndian().PutUint32(data, ethtoolGDrvInfo)
+	if err := e.ioctl(device, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (e *ethtoolIoctl) DriverInfo(device string) (ethtoolDriverInfo, error) {
+	data, err := e.driverInfo(device)
+	if err != nil {
+		return ethtoolDriverInfo{}, err
+	}
+	return ethtoolDriverInfo{
+		driver:          ethtoolString(data[4:36]),
+		version:         ethtoolString(data[36:68]),
+		firmwareVersion: ethtoolString(data[68:100]),
+		busInfo:         ethtoolString(data[100:132]),
+	}, nil
+}
+
+// Fetches the stat names with ETHTOOL_GSTRINGS and their values with
+// ETHTOOL_GSTATS, the number of stats comes from ETHTOOL_GDRVINFO.
+func (e *ethtoolIoctl) Stats(device string) (map[string]uint64, error) {
+	order := nativeEndian()
+	info, err := e.driverInfo(device)
+	if err != nil {
+		return nil, err
+	}
+	n := order.Uint32(info[ethtoolDrvInfoNStats:])
+	if n == 0 {
+		return map[string]uint64{}, nil
+	}
+
+	names := make([]byte, 12+ethGStringLen*n)
+	order.PutUint32(names[0:], ethtoolGStrings)
+	order.PutUint32(names[4:], ethSSStats)
+	order.PutUint32(names[8:], n)
+	if err := e.ioctl(device, names); err != nil {
+		return nil, err
+	}
+
+	values := make([]byte, 8+8*n)
+	order.PutUint32(values[0:], ethtoolGStats)
+	order.PutUint32(values[4:], n)
+	if err := e.ioctl(device, values); err != nil {
+		return nil, err
+	}
+
+	// The driver may have changed its number of stats in the meantime.
+	for _, l := range []uint32{order.Uint32(names[8:]), order.Uint32(values[4:])} {
+		if l < n {
+			n = l
+		}
+	}
+	stats := make(map[string]uint64, n)
+	for i := uint32(0); i < n; i++ {
+		name := ethtoolString(names[12+ethGStringLen*i : 12+ethGStringLen*(i+1)])
+		stats[name] = order.Uint64(values[8+8*i:])
+	}
+	return stats, nil
+}
+
+// Returns the NUL terminated string in b.
+func ethtoolString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
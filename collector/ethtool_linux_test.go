@@ -0,0 +1,326 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Canned ethtool responses by device, devices without stats don't support
+// ethtool.
+type fakeEthtool map[string]map[string]uint64
+
+func (f fakeEthtool) DriverInfo(device string) (ethtoolDriverInfo, error) {
+	if _, ok := f[device]; !ok {
+		return ethtoolDriverInfo{}, syscall.EOPNOTSUPP
+	}
+	return ethtoolDriverInfo{
+		driver:          "e1000e",
+		version:         "3.2.6-k",
+		firmwareVersion: "0.13-4",
+		busInfo:         "0000:00:19.0",
+	}, nil
+}
+
+func (f fakeEthtool) Stats(device string) (map[string]uint64, error) {
+	return f[device], nil
+}
+
+// Returns the values of the ethtool metrics by name and device, with the
+// driver of node_ethtool_info as value.
+func updateEthtool(e ethtool, ignored, include string) (map[string]map[string]interface{}, error) {
+	defer flag.Set("collector.sysfs", *sysPath)
+	defer flag.Set("collector.ethtool.ignored-devices", *ethtoolIgnoredDevices)
+	defer flag.Set("collector.ethtool.include", *ethtoolInclude)
+	if err := flag.Set("collector.sysfs", "fixtures/sys"); err != nil {
+		return nil, err
+	}
+	if err := flag.Set("collector.ethtool.ignored-devices", ignored); err != nil {
+		return nil, err
+	}
+	if err := flag.Set("collector.ethtool.include", include); err != nil {
+		return nil, err
+	}
+	c, err := newEthtoolCollector(e)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan prometheus.Metric, 1000)
+	if err := c.Update(ch); err != nil {
+		return nil, err
+	}
+	close(ch)
+
+	metrics := map[string]map[string]interface{}{}
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			return nil, err
+		}
+		labels := map[string]string{}
+		for _, l := range metric.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		name := prometheus.BuildFQName(Namespace, ethtoolSubsystem, "info")
+		var value interface{} = labels["driver"]
+		if m.Desc() != c.infoDesc {
+			name = c.statName(m.Desc())
+			value = metric.GetUntyped().GetValue()
+		}
+		if metrics[name] == nil {
+			metrics[name] = map[string]interface{}{}
+		}
+		metrics[name][labels["device"]] = value
+	}
+	return metrics, nil
+}
+
+// Returns the metric name of a desc created by the collector.
+func (c *ethtoolCollector) statName(desc *prometheus.Desc) string {
+	for name, d := range c.statDescs {
+		if d == desc {
+			return prometheus.BuildFQName(Namespace, ethtoolSubsystem, name)
+		}
+	}
+	return ""
+}
+
+func TestEthtool(t *testing.T) {
+	e := fakeEthtool{
+		"eth0": {
+			"rx_packets":         3,
+			"rx_missed_errors":   401,
+			"rx_queue_0_drops":   7,
+			"port.rx_no_buffer":  12,
+			"port.fec-corrected": 5,
+		},
+		"wlan0": {
+			"rx_packets": 11,
+			"rx-packets": 12,
+		},
+		"bond0": {},
+	}
+
+	metrics, err := updateEthtool(e, "^$", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]map[string]interface{}{
+		"node_ethtool_info": {
+			"eth0":  "e1000e",
+			"wlan0": "e1000e",
+			"bond0": "e1000e",
+		},
+		"node_ethtool_rx_missed_errors":   {"eth0": 401.0},
+		"node_ethtool_port_rx_no_buffer":  {"eth0": 12.0},
+		"node_ethtool_port_fec_corrected": {"eth0": 5.0},
+		// rx-packets sorts first and wins over rx_packets.
+		"node_ethtool_rx_packets": {"eth0": 3.0, "wlan0": 12.0},
+	} {
+		if len(want) != len(metrics[name]) {
+			t.Errorf("want %s for %d devices, got %v", name, len(want), metrics[name])
+		}
+		for dev, v := range want {
+			if got := metrics[name][dev]; v != got {
+				t.Errorf("want %s of %s %v, got %v", name, dev, v, got)
+			}
+		}
+	}
+
+	if want, got := 6, len(metrics); want != got {
+		t.Errorf("want %d metrics, got %d", want, got)
+	}
+}
+
+func TestEthtoolFilters(t *testing.T) {
+	e := fakeEthtool{
+		"eth0": {
+			"rx_packets":       3,
+			"rx_missed_errors": 401,
+			"tx_packets":       4,
+		},
+		"wlan0": {
+			"rx_missed_errors": 2,
+		},
+	}
+
+	metrics, err := updateEthtool(e, "^wlan", "^rx_")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := metrics["node_ethtool_tx_packets"]; ok {
+		t.Error("want tx_packets to not be included, but it is")
+	}
+	if want, got := 401.0, metrics["node_ethtool_rx_missed_errors"]["eth0"]; want != got {
+		t.Errorf("want rx_missed_errors %v, got %v", want, got)
+	}
+	if _, ok := metrics["node_ethtool_info"]["wlan0"]; ok {
+		t.Error("want wlan0 to be ignored, but it isn't")
+	}
+}
+
+// Fails the requests of some devices like drivers in the middle of a reset.
+type failingEthtool struct {
+	fakeEthtool
+	errs map[string]error
+}
+
+func (f failingEthtool) DriverInfo(device string) (ethtoolDriverInfo, error) {
+	if err, ok := f.errs[device]; ok {
+		return ethtoolDriverInfo{}, err
+	}
+	return f.fakeEthtool.DriverInfo(device)
+}
+
+func TestEthtoolDeviceErrors(t *testing.T) {
+	e := failingEthtool{
+		fakeEthtool: fakeEthtool{
+			"eth0":  {"rx_packets": 3},
+			"wlan0": {"rx_packets": 11},
+			"bond0": {"rx_packets": 5},
+		},
+		errs: map[string]error{
+			"eth0":  syscall.EBUSY,
+			"bond0": syscall.EINVAL,
+		},
+	}
+
+	metrics, err := updateEthtool(e, "^$", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := map[string]interface{}{"wlan0": 11.0}, metrics["node_ethtool_rx_packets"]; len(got) != 1 || want["wlan0"] != got["wlan0"] {
+		t.Errorf("want rx_packets %v, got %v", want, got)
+	}
+}
+
+// Emulates SIOCETHTOOL of the kernel for eth0 with the given stats, using
+// the layouts of struct ethtool_drvinfo, ethtool_gstrings and ethtool_stats
+// of linux/ethtool.h.
+func fakeEthtoolIoctl(t *testing.T, names []string, values []uint64) func(string, []byte) error {
+	order := nativeEndian()
+	n := uint32(len(names))
+
+	return func(device string, data []byte) error {
+		if device != "eth0" {
+			return syscall.ENODEV
+		}
+		switch order.Uint32(data) {
+		case 0x03: // ETHTOOL_GDRVINFO
+			if want, got := 196, len(data); want != got {
+				t.Fatalf("want ethtool_drvinfo of %d bytes, got %d", want, got)
+			}
+			copy(data[4:36], "ixgbe")
+			copy(data[36:68], "5.1.0-k")
+			copy(data[68:100], "0x800007f5")
+			copy(data[100:132], "0000:01:00.0")
+			copy(data[132:164], "garbage")
+			order.PutUint32(data[176:], 3) // n_priv_flags
+			order.PutUint32(data[180:], n) // n_stats
+		case 0x1b: // ETHTOOL_GSTRINGS
+			if stringSet, l := order.Uint32(data[4:]), order.Uint32(data[8:]); stringSet != 1 || l != n {
+				t.Fatalf("want string set 1 of %d strings, got %d of %d", n, stringSet, l)
+			}
+			if want, got := 12+32*len(names), len(data); want != got {
+				t.Fatalf("want ethtool_gstrings of %d bytes, got %d", want, got)
+			}
+			for i, name := range names {
+				copy(data[12+32*i:12+32*(i+1)], name)
+			}
+		case 0x1d: // ETHTOOL_GSTATS
+			if want, got := n, order.Uint32(data[4:]); want != got {
+				t.Fatalf("want %d stats, got %d", want, got)
+			}
+			if want, got := 8+8*len(values), len(data); want != got {
+				t.Fatalf("want ethtool_stats of %d bytes, got %d", want, got)
+			}
+			for i, v := range values {
+				order.PutUint64(data[8+8*i:], v)
+			}
+		default:
+			return syscall.EOPNOTSUPP
+		}
+		return nil
+	}
+}
+
+func TestEthtoolIoctl(t *testing.T) {
+	names := []string{"rx_packets", "rx_missed_errors", "tx_queue_10_restart_queue_count_"}
+	e := &ethtoolIoctl{ioctl: fakeEthtoolIoctl(t, names, []uint64{1 << 40, 401, 7})}
+
+	info, err := e.DriverInfo("eth0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := (ethtoolDriverInfo{
+		driver:          "ixgbe",
+		version:         "5.1.0-k",
+		firmwareVersion: "0x800007f5",
+		busInfo:         "0000:01:00.0",
+	}), info; want != got {
+		t.Errorf("want driver info %+v, got %+v", want, got)
+	}
+
+	stats, err := e.Stats("eth0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]uint64{
+		"rx_packets":       1 << 40,
+		"rx_missed_errors": 401,
+		// Names of ETH_GSTRING_LEN aren't NUL terminated.
+		"tx_queue_10_restart_queue_count_": 7,
+	} {
+		if got, ok := stats[name]; !ok || want != got {
+			t.Errorf("want %s %d, got %d", name, want, got)
+		}
+	}
+	if want, got := 3, len(stats); want != got {
+		t.Errorf("want %d stats, got %v", want, stats)
+	}
+
+	if _, err := e.Stats("eth1"); err != syscall.ENODEV {
+		t.Errorf("want ENODEV for missing device, got %v", err)
+	}
+
+	empty := &ethtoolIoctl{ioctl: fakeEthtoolIoctl(t, nil, nil)}
+	if stats, err := empty.Stats("eth0"); err != nil || len(stats) != 0 {
+		t.Errorf("want no stats, got %v, %v", stats, err)
+	}
+}
+
+func TestEthtoolIfreq(t *testing.T) {
+	// sizeof(struct ifreq) with its union of struct ifmap.
+	want := uintptr(32)
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		want = 40
+	}
+	var ifr ethtoolIfreq
+	if got := unsafe.Sizeof(ifr); want != got {
+		t.Errorf("want ifreq of %d bytes, got %d", want, got)
+	}
+	if want, got := uintptr(16), unsafe.Offsetof(ifr.data); want != got {
+		t.Errorf("want ifr_data at offset %d, got %d", want, got)
+	}
+}
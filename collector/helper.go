@@ -14,10 +14,12 @@
 package collector
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io/ioutil"
 	"strconv"
 	"strings"
+	"unsafe"
 )
 
 func splitToInts(str string, sep string) (ints []int, err error) {
@@ -42,3 +44,13 @@ func readUintFromFile(path string) (uint64, error) {
 	}
 	return value, nil
 }
+
+// Returns the byte order of the machine, which is used by kernel interfaces
+// like the auxiliary vector and netlink.
+func nativeEndian() binary.ByteOrder {
+	var i uint16 = 1
+	if *(*byte)(unsafe.Pointer(&i)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
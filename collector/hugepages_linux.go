@@ -0,0 +1,156 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nohugepages
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	hugepagesSubsystem = "hugepages"
+)
+
+var (
+	hugepagesDirRE = regexp.MustCompile(`^hugepages-([0-9]+)kB$`)
+
+	// Files of a hugepages-<size>kB directory. The per NUMA node
+	// directories only have the ones marked as numa.
+	hugepagesFields = []struct {
+		file, name, help string
+		numa             bool
+	}{
+		{"nr_hugepages", "pages", "Number of huge pages in the pool.", true},
+		{"free_hugepages", "free_pages", "Number of huge pages in the pool that are not allocated.", true},
+		{"resv_hugepages", "reserved_pages", "Number of huge pages reserved but not yet allocated.", false},
+		{"surplus_hugepages", "surplus_pages", "Number of huge pages in the pool above the configured number, allocated due to overcommit.", true},
+		{"nr_overcommit_hugepages", "overcommit_max_pages", "Maximum number of surplus huge pages.", false},
+	}
+)
+
+// The pool of huge pages of one size, either system-wide or of a NUMA node.
+type hugepagesPool struct {
+	node, size string
+	values     map[string]float64 // file -> value
+}
+
+type hugepagesCollector struct {
+	descs, numaDescs []*prometheus.Desc
+}
+
+func init() {
+	Factories["hugepages"] = NewHugepagesCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// the huge page pools of every size, system-wide and per NUMA node.
+func NewHugepagesCollector() (Collector, error) {
+	c := &hugepagesCollector{}
+	for _, f := range hugepagesFields {
+		c.descs = append(c.descs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, hugepagesSubsystem, f.name),
+			f.help, []string{"size"}, nil,
+		))
+		var numaDesc *prometheus.Desc
+		if f.numa {
+			numaDesc = prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, hugepagesSubsystem, "numa_"+f.name),
+				f.help, []string{"node", "size"}, nil,
+			)
+		}
+		c.numaDescs = append(c.numaDescs, numaDesc)
+	}
+	return c, nil
+}
+
+func (c *hugepagesCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	pools, err := getHugepagesPools(sysFilePath("kernel/mm/hugepages"), "")
+	if err != nil {
+		return fmt.Errorf("couldn't get huge pages: %s", err)
+	}
+
+	nodes, err := filepath.Glob(sysFilePath("devices/system/node/node[0-9]*"))
+	if err != nil {
+		return fmt.Errorf("couldn't get NUMA nodes: %s", err)
+	}
+	for _, node := range nodes {
+		nodePools, err := getHugepagesPools(path.Join(node, "hugepages"), strings.TrimPrefix(path.Base(node), "node"))
+		if err != nil {
+			return fmt.Errorf("couldn't get huge pages of %s: %s", path.Base(node), err)
+		}
+		pools = append(pools, nodePools...)
+	}
+
+	for _, pool := range pools {
+		for i, f := range hugepagesFields {
+			v, ok := pool.values[f.file]
+			if !ok {
+				continue
+			}
+			if pool.node == "" {
+				ch <- prometheus.MustNewConstMetric(c.descs[i], prometheus.GaugeValue, v, pool.size)
+			} else if f.numa {
+				ch <- prometheus.MustNewConstMetric(c.numaDescs[i], prometheus.GaugeValue, v, pool.node, pool.size)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the pools of the hugepages-<size>kB directories in dir, labeled
+// with their size in bytes.
+func getHugepagesPools(dir, node string) ([]hugepagesPool, error) {
+	dirs, err := filepath.Glob(path.Join(dir, "hugepages-*"))
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make([]hugepagesPool, 0, len(dirs))
+	for _, d := range dirs {
+		matches := hugepagesDirRE.FindStringSubmatch(path.Base(d))
+		if matches == nil {
+			continue
+		}
+		kb, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		pool := hugepagesPool{
+			node:   node,
+			size:   strconv.FormatUint(kb*1024, 10),
+			values: map[string]float64{},
+		}
+		for _, f := range hugepagesFields {
+			v, err := readUintFromFile(path.Join(d, f.file))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			pool.values[f.file] = float64(v)
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
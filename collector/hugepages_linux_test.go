@@ -0,0 +1,56 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestHugepages(t *testing.T) {
+	pools, err := getHugepagesPools("fixtures/sys/kernel/mm/hugepages", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 2, len(pools); want != got {
+		t.Fatalf("want %d pools, got %d", want, got)
+	}
+
+	sizes := map[string]hugepagesPool{}
+	for _, p := range pools {
+		sizes[p.size] = p
+	}
+
+	if want, got := 312.0, sizes["2097152"].values["free_hugepages"]; want != got {
+		t.Errorf("want %f free 2MiB pages, got %f", want, got)
+	}
+
+	if want, got := 4.0, sizes["1073741824"].values["nr_hugepages"]; want != got {
+		t.Errorf("want %f 1GiB pages, got %f", want, got)
+	}
+
+	pools, err = getHugepagesPools("fixtures/sys/devices/system/node/node1/hugepages", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range pools {
+		if _, ok := p.values["resv_hugepages"]; ok {
+			t.Errorf("want no reserved pages for NUMA node, got %v", p.values)
+		}
+		if want, got := "1", p.node; want != got {
+			t.Errorf("want node %s, got %s", want, got)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nohwmon
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	hwmonSubsystem   = "hwmon"
+	thermalSubsystem = "thermal_zone"
+)
+
+var (
+	hwmonAttributeRE = regexp.MustCompile(`^(temp|in|fan|power|curr)(\d+)_([a-z_]+)$`)
+	hwmonInvalidRE   = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+	// Sensor types with the unit they're exported in and the divisor to
+	// convert the sysfs value to it. See
+	// Documentation/hwmon/sysfs-interface in the kernel sources.
+	hwmonSensorTypes = map[string]struct {
+		name, unit string
+		divisor    float64
+	}{
+		"temp":  {"temperature", "celsius", 1000}, // millidegree Celsius
+		"in":    {"voltage", "volts", 1000},       // millivolt
+		"fan":   {"fan speed", "rpm", 1},          // revolutions per minute
+		"power": {"power", "watts", 1e6},          // microwatt
+		"curr":  {"current", "amps", 1000},        // milliampere
+	}
+
+	// Attributes of a sensor with a value in its unit. Power sensors often
+	// only provide an average.
+	hwmonValueAttributes = map[string]bool{"input": true, "min": true, "max": true, "crit": true, "average": true}
+	// Attributes of a sensor that are 1 if an alarm is raised.
+	hwmonAlarmAttributes = map[string]bool{"alarm": true, "min_alarm": true, "max_alarm": true, "crit_alarm": true}
+)
+
+type hwmonSensor struct {
+	typ, label string
+	values     map[string]float64 // attribute -> value
+}
+
+type hwmonChip struct {
+	id, name string
+	sensors  map[string]*hwmonSensor // sensor, e.g. temp1 -> sensor
+}
+
+type hwmonCollector struct {
+	metricDescs                   map[string]*prometheus.Desc
+	chipNameDesc, sensorLabelDesc *prometheus.Desc
+	thermalZoneTempDesc           *prometheus.Desc
+}
+
+func init() {
+	Factories["hwmon"] = NewHwmonCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// hardware monitoring and thermal zone sensors.
+func NewHwmonCollector() (Collector, error) {
+	return &hwmonCollector{
+		metricDescs: map[string]*prometheus.Desc{},
+		chipNameDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, hwmonSubsystem, "chip_names"),
+			"Name of the hardware monitoring chip as reported by its driver.",
+			[]string{"chip", "chip_name"}, nil,
+		),
+		sensorLabelDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, hwmonSubsystem, "sensor_label"),
+			"Label of the sensor as provided by the driver or sensors configuration.",
+			[]string{"chip", "sensor", "label"}, nil,
+		),
+		thermalZoneTempDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, thermalSubsystem, "temp_celsius"),
+			"Temperature of the thermal zone.",
+			[]string{"zone", "type"}, nil,
+		),
+	}, nil
+}
+
+func (c *hwmonCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	chips, err := getHwmonChips()
+	if err != nil {
+		return fmt.Errorf("couldn't get hwmon chips: %s", err)
+	}
+	for _, chip := range chips {
+		ch <- prometheus.MustNewConstMetric(c.chipNameDesc, prometheus.GaugeValue, 1, chip.id, chip.name)
+		for sensor, s := range chip.sensors {
+			if s.label != "" {
+				ch <- prometheus.MustNewConstMetric(c.sensorLabelDesc, prometheus.GaugeValue, 1, chip.id, sensor, s.label)
+			}
+			for attr, v := range s.values {
+				ch <- prometheus.MustNewConstMetric(c.desc(s.typ, attr), prometheus.GaugeValue, v, chip.id, sensor)
+			}
+		}
+	}
+
+	zones, err := getThermalZones()
+	if err != nil {
+		return fmt.Errorf("couldn't get thermal zones: %s", err)
+	}
+	for zone, z := range zones {
+		ch <- prometheus.MustNewConstMetric(c.thermalZoneTempDesc, prometheus.GaugeValue, z.temp, zone, z.typ)
+	}
+	return nil
+}
+
+// Returns the desc of the attribute of a sensor type, e.g. the max of temp
+// is node_hwmon_temp_max_celsius.
+func (c *hwmonCollector) desc(typ, attr string) *prometheus.Desc {
+	key := typ + "_" + attr
+	if desc, ok := c.metricDescs[key]; ok {
+		return desc
+	}
+
+	t := hwmonSensorTypes[typ]
+	var name, help string
+	switch {
+	case hwmonAlarmAttributes[attr]:
+		name = key
+		help = fmt.Sprintf("Hardware monitor %s %s, 1 if raised.", t.name, strings.Replace(attr, "_", " ", -1))
+	case attr == "input":
+		name = typ + "_" + t.unit
+		help = fmt.Sprintf("Hardware monitor %s input.", t.name)
+	default:
+		name = key + "_" + t.unit
+		help = fmt.Sprintf("Hardware monitor %s %s.", t.name, attr)
+	}
+	desc := prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, hwmonSubsystem, name),
+		help, []string{"chip", "sensor"}, nil,
+	)
+	c.metricDescs[key] = desc
+	return desc
+}
+
+func getHwmonChips() ([]hwmonChip, error) {
+	dirs, err := filepath.Glob(sysFilePath("class/hwmon/hwmon*"))
+	if err != nil {
+		return nil, err
+	}
+
+	chips := make([]hwmonChip, 0, len(dirs))
+	for _, dir := range dirs {
+		chip, err := readHwmonChip(dir)
+		if err != nil {
+			return nil, err
+		}
+		chips = append(chips, chip)
+	}
+	return chips, nil
+}
+
+// Reads the chip of a /sys/class/hwmon/hwmon<n> link. Drivers predating
+// the hwmon class keep their attributes in the device directory.
+func readHwmonChip(dir string) (hwmonChip, error) {
+	chip := hwmonChip{
+		id:      hwmonChipID(dir),
+		sensors: map[string]*hwmonSensor{},
+	}
+
+	attrDir := dir
+	if _, err := os.Stat(path.Join(dir, "name")); os.IsNotExist(err) {
+		attrDir = path.Join(dir, "device")
+	}
+	name, err := ioutil.ReadFile(path.Join(attrDir, "name"))
+	if err != nil {
+		return chip, err
+	}
+	chip.name = strings.TrimSpace(string(name))
+
+	files, err := ioutil.ReadDir(attrDir)
+	if err != nil {
+		return chip, err
+	}
+	for _, f := range files {
+		matches := hwmonAttributeRE.FindStringSubmatch(f.Name())
+		if matches == nil {
+			continue
+		}
+		typ, sensor, attr := matches[1], matches[1]+matches[2], matches[3]
+		if attr != "label" && !hwmonValueAttributes[attr] && !hwmonAlarmAttributes[attr] {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path.Join(attrDir, f.Name()))
+		if err != nil {
+			// Drivers return errors for sensors that aren't connected.
+			log.Debugf("Ignoring hwmon attribute %s of %s: %s", f.Name(), chip.id, err)
+			continue
+		}
+		value := strings.TrimSpace(string(data))
+
+		s, ok := chip.sensors[sensor]
+		if !ok {
+			s = &hwmonSensor{typ: typ, values: map[string]float64{}}
+			chip.sensors[sensor] = s
+		}
+		if attr == "label" {
+			s.label = value
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return chip, fmt.Errorf("invalid value %s in %s of %s: %s", value, f.Name(), chip.id, err)
+		}
+		if hwmonValueAttributes[attr] {
+			v /= hwmonSensorTypes[typ].divisor
+		}
+		s.values[attr] = v
+	}
+	return chip, nil
+}
+
+// Returns a stable identifier of a chip from the path of its device, e.g.
+// platform_coretemp_0. hwmon<n> itself depends on probing order. Virtual
+// chips without a device keep hwmon<n>.
+func hwmonChipID(dir string) string {
+	device, err := filepath.EvalSymlinks(path.Join(dir, "device"))
+	if err != nil {
+		return path.Base(dir)
+	}
+	devices, err := filepath.EvalSymlinks(sysFilePath("devices"))
+	if err != nil {
+		return path.Base(dir)
+	}
+	rel, err := filepath.Rel(devices, device)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path.Base(dir)
+	}
+	return strings.Trim(hwmonInvalidRE.ReplaceAllString(rel, "_"), "_")
+}
+
+type thermalZone struct {
+	typ  string
+	temp float64 // celsius
+}
+
+// Returns the thermal zones by their number.
+func getThermalZones() (map[string]thermalZone, error) {
+	dirs, err := filepath.Glob(sysFilePath("class/thermal/thermal_zone*"))
+	if err != nil {
+		return nil, err
+	}
+
+	zones := map[string]thermalZone{}
+	for _, dir := range dirs {
+		typ, err := ioutil.ReadFile(path.Join(dir, "type"))
+		if err != nil {
+			return nil, err
+		}
+		temp, err := ioutil.ReadFile(path.Join(dir, "temp"))
+		if err != nil {
+			// Zones of disabled or unavailable sensors return errors.
+			log.Debugf("Ignoring thermal zone %s: %s", dir, err)
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(string(temp)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid temperature of %s: %s", dir, err)
+		}
+		zones[strings.TrimPrefix(path.Base(dir), "thermal_zone")] = thermalZone{
+			typ:  strings.TrimSpace(string(typ)),
+			temp: v / 1000, // millidegree Celsius
+		}
+	}
+	return zones, nil
+}
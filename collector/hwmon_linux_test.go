@@ -0,0 +1,101 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestHwmon(t *testing.T) {
+	if err := flag.Set("collector.sysfs", "fixtures/sys"); err != nil {
+		t.Fatal(err)
+	}
+
+	chips, err := getHwmonChips()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 5, len(chips); want != got {
+		t.Fatalf("want %d chips, got %d", want, got)
+	}
+	byName := map[string]hwmonChip{}
+	for _, chip := range chips {
+		byName[chip.name] = chip
+	}
+
+	coretemp := byName["coretemp"]
+	if want, got := "platform_coretemp_0", coretemp.id; want != got {
+		t.Errorf("want chip %s, got %s", want, got)
+	}
+
+	if want, got := 55.0, coretemp.sensors["temp1"].values["input"]; want != got {
+		t.Errorf("want temperature %f, got %f", want, got)
+	}
+
+	if want, got := "Package id 0", coretemp.sensors["temp1"].label; want != got {
+		t.Errorf("want label %q, got %q", want, got)
+	}
+
+	nct := byName["nct6779"]
+	if want, got := 1.0, nct.sensors["in1"].values["alarm"]; want != got {
+		t.Errorf("want alarm %f, got %f", want, got)
+	}
+
+	if want, got := 1098.0, nct.sensors["fan1"].values["input"]; want != got {
+		t.Errorf("want fan speed %f, got %f", want, got)
+	}
+
+	if want, got := "hwmon2", byName["acpitz"].id; want != got {
+		t.Errorf("want chip without device %s, got %s", want, got)
+	}
+
+	k10temp := byName["k10temp"]
+	if want, got := "pci0000_00_0000_00_18_3", k10temp.id; want != got {
+		t.Errorf("want chip %s, got %s", want, got)
+	}
+
+	if want, got := 90.0, k10temp.sensors["temp1"].values["crit"]; want != got {
+		t.Errorf("want critical temperature %f, got %f", want, got)
+	}
+
+	ina := byName["ina226"]
+	if want, got := 18.25, ina.sensors["power1"].values["input"]; want != got {
+		t.Errorf("want power %f, got %f", want, got)
+	}
+
+	if want, got := 1.52, ina.sensors["curr1"].values["input"]; want != got {
+		t.Errorf("want current %f, got %f", want, got)
+	}
+}
+
+func TestThermalZones(t *testing.T) {
+	if err := flag.Set("collector.sysfs", "fixtures/sys"); err != nil {
+		t.Fatal(err)
+	}
+
+	zones, err := getThermalZones()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 2, len(zones); want != got {
+		t.Fatalf("want %d thermal zones, got %d", want, got)
+	}
+
+	if want, got := (thermalZone{typ: "x86_pkg_temp", temp: 55}), zones["1"]; want != got {
+		t.Errorf("want thermal zone %v, got %v", want, got)
+	}
+}
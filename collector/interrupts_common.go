@@ -16,10 +16,45 @@
 
 package collector
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	interruptsInclude = flag.String(
+		"collector.interrupts.include", "",
+		"Regexp of interrupts to include, matched against the interrupt and its devices. Empty includes all.")
+	interruptsExclude = flag.String(
+		"collector.interrupts.exclude", "",
+		"Regexp of interrupts to exclude, matched against the interrupt and its devices. Empty excludes none.")
+	interruptsAggregate = flag.String(
+		"collector.interrupts.aggregate", "",
+		"Comma separated dimensions to sum interrupts across: cpu, device or both. "+
+			"Aggregating by device sums the queues of a device, interrupts without devices keep their type as device.")
+	interruptsQueuePattern = flag.String(
+		"collector.interrupts.queue-pattern", `[-_.]?(TxRx|txrx|rx|tx|input|output|q)[-_.]?[0-9]+$`,
+		"Regexp matching the queue suffix of device names, removed when aggregating by device.")
+)
 
 type interruptsCollector struct {
-	metric *prometheus.CounterVec
+	metric          *prometheus.CounterVec
+	labelNames      []string
+	include         *regexp.Regexp
+	exclude         *regexp.Regexp
+	queuePattern    *regexp.Regexp
+	aggregateCPU    bool
+	aggregateDevice bool
+}
+
+// A series of the interrupts metric and the sum of the interrupts in it.
+type interruptSeries struct {
+	labelValues []string
+	value       float64
 }
 
 func init() {
@@ -29,14 +64,95 @@ func init() {
 // Takes a prometheus registry and returns a new Collector exposing
 // interrupts stats
 func NewInterruptsCollector() (Collector, error) {
-	return &interruptsCollector{
-		metric: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Namespace: Namespace,
-				Name:      "interrupts",
-				Help:      "Interrupt details.",
-			},
-			interruptLabelNames,
-		),
-	}, nil
+	c := &interruptsCollector{}
+	for _, dimension := range strings.Split(*interruptsAggregate, ",") {
+		switch strings.TrimSpace(dimension) {
+		case "":
+		case "cpu":
+			c.aggregateCPU = true
+		case "device":
+			c.aggregateDevice = true
+		default:
+			return nil, fmt.Errorf("invalid interrupts aggregation: %s", dimension)
+		}
+	}
+
+	var err error
+	for _, p := range []struct {
+		flag    string
+		pattern **regexp.Regexp
+	}{
+		{*interruptsInclude, &c.include},
+		{*interruptsExclude, &c.exclude},
+		{*interruptsQueuePattern, &c.queuePattern},
+	} {
+		if p.flag == "" {
+			continue
+		}
+		if *p.pattern, err = regexp.Compile(p.flag); err != nil {
+			return nil, fmt.Errorf("invalid interrupts pattern %s: %s", p.flag, err)
+		}
+	}
+
+	for _, name := range interruptLabelNames {
+		if c.aggregateCPU && name == "CPU" {
+			continue
+		}
+		if c.aggregateDevice && name != "CPU" && name != "devices" {
+			continue
+		}
+		c.labelNames = append(c.labelNames, name)
+	}
+	c.metric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "interrupts",
+			Help:      "Interrupt details.",
+		},
+		c.labelNames,
+	)
+	return c, nil
+}
+
+// Returns whether the interrupt passes the include and exclude patterns.
+func (c *interruptsCollector) included(name, devices string) bool {
+	if c.include != nil && !c.include.MatchString(name) && !c.include.MatchString(devices) {
+		return false
+	}
+	if c.exclude != nil && (c.exclude.MatchString(name) || c.exclude.MatchString(devices)) {
+		return false
+	}
+	return true
+}
+
+// Adds the value of an interrupt on a CPU to its series, which sums up the
+// values of all interrupts that only differ in aggregated labels.
+func (c *interruptsCollector) add(series map[string]*interruptSeries, labels prometheus.Labels, value float64) {
+	if c.aggregateDevice {
+		device := labels["devices"]
+		if c.queuePattern != nil {
+			device = c.queuePattern.ReplaceAllString(device, "")
+		}
+		if device == "" {
+			device = labels["type"]
+		}
+		labels["devices"] = device
+	}
+
+	values := make([]string, 0, len(c.labelNames))
+	for _, name := range c.labelNames {
+		values = append(values, labels[name])
+	}
+	key := strings.Join(values, "\xff")
+	if s, ok := series[key]; ok {
+		s.value += value
+		return
+	}
+	series[key] = &interruptSeries{labelValues: values, value: value}
+}
+
+func (c *interruptsCollector) set(series map[string]*interruptSeries) {
+	for _, s := range series {
+		c.metric.WithLabelValues(s.labelValues...).Set(s.value)
+	}
 }
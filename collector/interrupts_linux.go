@@ -20,15 +20,24 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
 )
 
 var (
 	interruptLabelNames = []string{"CPU", "type", "info", "devices"}
+
+	interruptAffinityDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "interrupts_affinity_info"),
+		"CPUs an interrupt may be handled on, from /proc/irq/<type>/smp_affinity_list.",
+		[]string{"type", "cpus"}, nil,
+	)
 )
 
 func (c *interruptsCollector) Update(ch chan<- prometheus.Metric) (err error) {
@@ -36,7 +45,11 @@ func (c *interruptsCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	if err != nil {
 		return fmt.Errorf("couldn't get interrupts: %s", err)
 	}
+	series := map[string]*interruptSeries{}
 	for name, interrupt := range interrupts {
+		if !c.included(name, interrupt.devices) {
+			continue
+		}
 		for cpuNo, value := range interrupt.values {
 			fv, err := strconv.ParseFloat(value, 64)
 			if err != nil {
@@ -48,9 +61,21 @@ func (c *interruptsCollector) Update(ch chan<- prometheus.Metric) (err error) {
 				"info":    interrupt.info,
 				"devices": interrupt.devices,
 			}
-			c.metric.With(labels).Set(fv)
+			c.add(series, labels, fv)
+		}
+
+		affinity, err := ioutil.ReadFile(procFilePath(path.Join("irq", name, "smp_affinity_list")))
+		if os.IsNotExist(err) {
+			// Only numbered interrupts have an affinity.
+			continue
+		}
+		if err != nil {
+			log.Errorf("Couldn't get affinity of interrupt %s: %s", name, err)
+			continue
 		}
+		ch <- prometheus.MustNewConstMetric(interruptAffinityDesc, prometheus.GaugeValue, 1, name, strings.TrimSpace(string(affinity)))
 	}
+	c.set(series)
 	c.metric.Collect(ch)
 	return err
 }
@@ -90,7 +115,7 @@ func parseInterrupts(r io.Reader) (map[string]interrupt, error) {
 		}
 		intName := parts[0][:len(parts[0])-1] // remove trailing :
 		intr := interrupt{
-			values: parts[1:cpuNum],
+			values: parts[1 : cpuNum+1],
 		}
 
 		if _, err := strconv.Atoi(intName); err == nil { // numeral interrupt
@@ -14,8 +14,14 @@
 package collector
 
 import (
+	"flag"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestInterrupts(t *testing.T) {
@@ -33,4 +39,213 @@ func TestInterrupts(t *testing.T) {
 	if want, got := "5031", interrupts["NMI"].values[1]; want != got {
 		t.Errorf("want interrupts %s, got %s", want, got)
 	}
+
+	if want, got := 4, len(interrupts["NMI"].values); want != got {
+		t.Errorf("want interrupts of %d CPUs, got %d", want, got)
+	}
+
+	if want, got := "4968", interrupts["NMI"].values[3]; want != got {
+		t.Errorf("want interrupts %s on the last CPU, got %s", want, got)
+	}
+
+	if want, got := "eth0-TxRx-2", interrupts["50"].devices; want != got {
+		t.Errorf("want devices %s, got %s", want, got)
+	}
+}
+
+func TestInterruptsAggregation(t *testing.T) {
+	for _, c := range []struct {
+		include, exclude, aggregate string
+		want                        map[[2]string]float64 // first two label values -> value
+		series                      int
+	}{
+		{
+			// The last CPU column of /proc/interrupts.
+			include: "^eth0-TxRx-3$",
+			want: map[[2]string]float64{
+				{"3", "eth0-TxRx-3"}: 2102911,
+			},
+			series: 4,
+		},
+		{
+			include:   "^eth0",
+			aggregate: "cpu",
+			want: map[[2]string]float64{
+				{"eth0-TxRx-0", "IR-PCI-MSI-edge"}: 1837421,
+				{"eth0", "IR-PCI-MSI-edge"}:        2,
+			},
+			series: 5,
+		},
+		{
+			aggregate: "device",
+			want: map[[2]string]float64{
+				{"1", "eth0"}:   2210342,
+				{"3", "i8042"}:  226,
+				{"0", "NMI"}:    47,
+				{"2", "ahci"}:   6478877,
+				{"3", "mei_me"}: 0,
+			},
+			series: 4 * 28,
+		},
+		{
+			exclude:   "^[A-Z]+$",
+			aggregate: "cpu,device",
+			want: map[[2]string]float64{
+				{"eth0"}:  8126906,
+				{"timer"}: 18,
+			},
+			series: 15,
+		},
+	} {
+		metrics, err := updateInterrupts(c.include, c.exclude, c.aggregate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := c.series, len(metrics); want != got {
+			t.Errorf("want %d series for %+v, got %d", want, c, got)
+		}
+		for labels, want := range c.want {
+			got, ok := metrics[labels]
+			if !ok || want != got {
+				t.Errorf("want %f for %v with %+v, got %f", want, labels, c, got)
+			}
+		}
+	}
+}
+
+func TestInterruptsAffinity(t *testing.T) {
+	affinity, err := updateInterruptsAffinity("fixtures/proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 20, len(affinity); want != got {
+		t.Errorf("want affinity of %d interrupts, got %d", want, got)
+	}
+	for irq, want := range map[string]string{"0": "0", "46": "0,2", "49": "1", "52": "0-3"} {
+		if got := affinity[irq]; want != got {
+			t.Errorf("want affinity %s of interrupt %s, got %s", want, irq, got)
+		}
+	}
+}
+
+func TestInterruptsAffinityReadError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	interrupts, err := ioutil.ReadFile("fixtures/proc/interrupts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "interrupts"), interrupts, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Reading a directory fails with EISDIR.
+	if err := os.MkdirAll(filepath.Join(dir, "irq/0/smp_affinity_list"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "irq/1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "irq/1/smp_affinity_list"), []byte("0-3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	affinity, err := updateInterruptsAffinity(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := map[string]string{"1": "0-3"}, affinity; len(got) != 1 || want["1"] != got["1"] {
+		t.Errorf("want affinity %v, got %v", want, got)
+	}
+}
+
+// Runs the interrupts collector on procfs and returns the CPUs of the
+// affinity metric by interrupt.
+func updateInterruptsAffinity(procfs string) (map[string]string, error) {
+	defer flag.Set("collector.procfs", *procPath)
+	if err := flag.Set("collector.procfs", procfs); err != nil {
+		return nil, err
+	}
+	c, err := NewInterruptsCollector()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan prometheus.Metric, 1000)
+	if err := c.Update(ch); err != nil {
+		return nil, err
+	}
+	close(ch)
+
+	affinity := map[string]string{}
+	for m := range ch {
+		if m.Desc() != interruptAffinityDesc {
+			continue
+		}
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			return nil, err
+		}
+		labels := map[string]string{}
+		for _, l := range metric.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		affinity[labels["type"]] = labels["cpus"]
+	}
+	return affinity, nil
+}
+
+// Runs the interrupts collector on the fixtures with the given flags and
+// returns the value of each series of the interrupts metric by its first
+// two label values.
+func updateInterrupts(include, exclude, aggregate string) (map[[2]string]float64, error) {
+	for name, value := range map[string]string{
+		"collector.procfs":               "fixtures/proc",
+		"collector.interrupts.include":   include,
+		"collector.interrupts.exclude":   exclude,
+		"collector.interrupts.aggregate": aggregate,
+	} {
+		if err := flag.Set(name, value); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		for _, name := range []string{"collector.interrupts.include", "collector.interrupts.exclude", "collector.interrupts.aggregate"} {
+			flag.Set(name, "")
+		}
+	}()
+
+	collector, err := NewInterruptsCollector()
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan prometheus.Metric, 1000)
+	if err := collector.Update(ch); err != nil {
+		return nil, err
+	}
+	close(ch)
+
+	metrics := map[[2]string]float64{}
+	for m := range ch {
+		if m.Desc() == interruptAffinityDesc {
+			continue
+		}
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			return nil, err
+		}
+		// Labels are sorted by name: CPU, devices, info, type.
+		var key [2]string
+		for i, l := range metric.GetLabel() {
+			if i < len(key) {
+				key[i] = l.GetValue()
+			}
+		}
+		metrics[key] = metric.GetCounter().GetValue()
+	}
+	return metrics, nil
 }
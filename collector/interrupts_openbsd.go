@@ -103,16 +103,22 @@ func (c *interruptsCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	if err != nil {
 		return fmt.Errorf("couldn't get interrupts: %s", err)
 	}
+	series := map[string]*interruptSeries{}
 	for dev, interrupt := range interrupts {
+		vector := fmt.Sprintf("%d", interrupt.vector)
+		if !c.included(vector, dev) {
+			continue
+		}
 		for cpuNo, value := range interrupt.values {
 			labels := prometheus.Labels{
 				"CPU":     strconv.Itoa(cpuNo),
-				"type":    fmt.Sprintf("%d", interrupt.vector),
+				"type":    vector,
 				"devices": dev,
 			}
-			c.metric.With(labels).Set(value)
+			c.add(series, labels, value)
 		}
 	}
+	c.set(series)
 	c.metric.Collect(ch)
 	return err
 }
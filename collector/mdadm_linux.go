@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -30,6 +32,15 @@ import (
 var (
 	statuslineRE = regexp.MustCompile(`(\d+) blocks .*\[(\d+)/(\d+)\] \[[U_]+\]`)
 	buildlineRE  = regexp.MustCompile(`\((\d+)/\d+\)`)
+	speedRE      = regexp.MustCompile(`speed=(\d+)K/sec`)
+	finishRE     = regexp.MustCompile(`finish=([\d.]+)min`)
+
+	// States an md-device can be in, as exported by node_md_state. Array
+	// states of md(4) without a member here, like clear or suspended, are
+	// exported as unknown.
+	mdArrayStates = []string{"clean", "active", "inactive", "readonly", "degraded", "resync", "recover", "check", "reshape", "unknown"}
+	// Member disk flags exported by node_md_disk_state.
+	mdDiskStates = []string{"faulty", "spare", "in_sync"}
 )
 
 type mdStatus struct {
@@ -39,6 +50,17 @@ type mdStatus struct {
 	disksTotal   int64
 	blocksTotal  int64
 	blocksSynced int64
+	syncSpeed    float64 // bytes per second
+	syncFinish   float64 // seconds
+}
+
+// State of an md-device as found in /sys/block/md*/md.
+type mdSysfsStatus struct {
+	arrayState  string
+	syncAction  string
+	degraded    int64
+	mismatchCnt int64
+	disks       map[string][]string // member disk -> state flags
 }
 
 type mdadmCollector struct{}
@@ -98,6 +120,28 @@ func evalBuildline(buildline string) (int64, error) {
 	return syncedSize, nil
 }
 
+// Gets the current sync speed in bytes per second and the estimated time
+// until the sync finishes in seconds out of the sync-line.
+func evalSyncRate(buildline string) (speed, finish float64, err error) {
+	if matches := speedRE.FindStringSubmatch(buildline); matches != nil {
+		speed, err = strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s in buildline: %s", err, buildline)
+		}
+		speed *= 1024
+	}
+
+	if matches := finishRE.FindStringSubmatch(buildline); matches != nil {
+		finish, err = strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s in buildline: %s", err, buildline)
+		}
+		finish *= 60
+	}
+
+	return speed, finish, nil
+}
+
 // Parses an mdstat-file and returns a struct with the relevant infos.
 func parseMdstat(mdStatusFilePath string) ([]mdStatus, error) {
 	content, err := ioutil.ReadFile(mdStatusFilePath)
@@ -150,7 +194,11 @@ func parseMdstat(mdStatusFilePath string) ([]mdStatus, error) {
 		}
 
 		// Now get the number of synced blocks.
-		var syncedBlocks int64
+		var (
+			syncedBlocks int64
+			speed        float64
+			finish       float64
+		)
 
 		// Get the line number of the syncing-line.
 		var j int
@@ -162,22 +210,101 @@ func parseMdstat(mdStatusFilePath string) ([]mdStatus, error) {
 
 		// If device is syncing at the moment, get the number of currently synced bytes,
 		// otherwise that number equals the size of the device.
-		if strings.Contains(lines[j], "recovery") || strings.Contains(lines[j], "resync") && !strings.Contains(lines[j], "resync=DELAYED") {
+		if strings.Contains(lines[j], "recovery") || strings.Contains(lines[j], "reshape") || strings.Contains(lines[j], "check") ||
+			strings.Contains(lines[j], "resync") && !strings.Contains(lines[j], "resync=DELAYED") {
 			syncedBlocks, err = evalBuildline(lines[j])
 			if err != nil {
 				return mdStates, fmt.Errorf("error parsing mdstat: %s", err)
 			}
+			speed, finish, err = evalSyncRate(lines[j])
+			if err != nil {
+				return mdStates, fmt.Errorf("error parsing mdstat: %s", err)
+			}
 		} else {
 			syncedBlocks = size
 		}
 
-		mdStates = append(mdStates, mdStatus{currentMD, isActive, active, total, size, syncedBlocks})
+		mdStates = append(mdStates, mdStatus{currentMD, isActive, active, total, size, syncedBlocks, speed, finish})
 
 	}
 
 	return mdStates, nil
 }
 
+// Reads the state of an md-device from its sysfs directory. Files which only
+// exist for redundant RAID levels are optional.
+func parseMdSysfs(mdDir string) (mdSysfsStatus, error) {
+	status := mdSysfsStatus{disks: map[string][]string{}}
+
+	arrayState, err := ioutil.ReadFile(path.Join(mdDir, "array_state"))
+	if err != nil {
+		return status, err
+	}
+	status.arrayState = strings.TrimSpace(string(arrayState))
+
+	syncAction, err := ioutil.ReadFile(path.Join(mdDir, "sync_action"))
+	switch {
+	case err == nil:
+		status.syncAction = strings.TrimSpace(string(syncAction))
+	case os.IsNotExist(err):
+		status.syncAction = "idle"
+	default:
+		return status, err
+	}
+
+	for file, value := range map[string]*int64{"degraded": &status.degraded, "mismatch_cnt": &status.mismatchCnt} {
+		v, err := readUintFromFile(path.Join(mdDir, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return status, err
+		}
+		*value = int64(v)
+	}
+
+	disks, err := filepath.Glob(path.Join(mdDir, "dev-*"))
+	if err != nil {
+		return status, err
+	}
+	for _, disk := range disks {
+		state, err := ioutil.ReadFile(path.Join(disk, "state"))
+		if err != nil {
+			return status, err
+		}
+		name := strings.TrimPrefix(path.Base(disk), "dev-")
+		status.disks[name] = strings.Split(strings.TrimSpace(string(state)), ",")
+	}
+
+	return status, nil
+}
+
+// Reduces the sysfs state of an md-device to one of mdArrayStates. Running
+// sync actions take precedence over a degraded array, which in turn takes
+// precedence over the array_state.
+func mdArrayState(status mdSysfsStatus) string {
+	switch status.syncAction {
+	case "resync", "recover", "check", "reshape":
+		return status.syncAction
+	case "repair":
+		return "check"
+	}
+
+	if status.degraded > 0 {
+		return "degraded"
+	}
+
+	switch status.arrayState {
+	case "clean", "inactive":
+		return status.arrayState
+	case "active", "active-idle", "write-pending":
+		return "active"
+	case "readonly", "read-auto":
+		return "readonly"
+	}
+	return "unknown"
+}
+
 // Just returns the pointer to an empty struct as we only use throwaway-metrics.
 func NewMdadmCollector() (Collector, error) {
 	return &mdadmCollector{}, nil
@@ -218,6 +345,48 @@ var (
 		[]string{"device"},
 		nil,
 	)
+
+	syncSpeedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "sync_speed_bytes"),
+		"Current sync speed of device in bytes per second.",
+		[]string{"device"},
+		nil,
+	)
+
+	syncRemainingDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "sync_remaining_seconds"),
+		"Estimated time until the sync of device finishes.",
+		[]string{"device"},
+		nil,
+	)
+
+	mdStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "state"),
+		"Indicates the state of device.",
+		[]string{"device", "state"},
+		nil,
+	)
+
+	degradedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "degraded"),
+		"Number of missing or failed disks of device.",
+		[]string{"device"},
+		nil,
+	)
+
+	mismatchDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "mismatch_sectors"),
+		"Number of sectors found mismatched by the last check or repair of device.",
+		[]string{"device"},
+		nil,
+	)
+
+	diskStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "disk_state"),
+		"Indicates the state of a member disk of device.",
+		[]string{"device", "disk", "state"},
+		nil,
+	)
 )
 
 func (c *mdadmCollector) Update(ch chan<- prometheus.Metric) (err error) {
@@ -287,6 +456,61 @@ func (c *mdadmCollector) Update(ch chan<- prometheus.Metric) (err error) {
 			mds.mdName,
 		)
 
+		ch <- prometheus.MustNewConstMetric(
+			syncSpeedDesc,
+			prometheus.GaugeValue,
+			mds.syncSpeed,
+			mds.mdName,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			syncRemainingDesc,
+			prometheus.GaugeValue,
+			mds.syncFinish,
+			mds.mdName,
+		)
+
+		if err := updateMdSysfs(ch, mds.mdName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func updateMdSysfs(ch chan<- prometheus.Metric, device string) error {
+	mdDir := sysFilePath(path.Join("block", device, "md"))
+	status, err := parseMdSysfs(mdDir)
+	if os.IsNotExist(err) {
+		log.Debugf("Not collecting md sysfs state, directory does not exist: %s", mdDir)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing md sysfs state: %s", err)
+	}
+
+	state := mdArrayState(status)
+	for _, s := range mdArrayStates {
+		var v float64
+		if s == state {
+			v = 1
+		}
+		ch <- prometheus.MustNewConstMetric(mdStateDesc, prometheus.GaugeValue, v, device, s)
+	}
+
+	ch <- prometheus.MustNewConstMetric(degradedDesc, prometheus.GaugeValue, float64(status.degraded), device)
+	ch <- prometheus.MustNewConstMetric(mismatchDesc, prometheus.GaugeValue, float64(status.mismatchCnt), device)
+
+	for disk, flags := range status.disks {
+		for _, s := range mdDiskStates {
+			var v float64
+			for _, f := range flags {
+				if f == s {
+					v = 1
+				}
+			}
+			ch <- prometheus.MustNewConstMetric(diskStateDesc, prometheus.GaugeValue, v, device, disk, s)
+		}
 	}
 
 	return nil
@@ -14,6 +14,7 @@
 package collector
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -25,14 +26,16 @@ func TestMdadm(t *testing.T) {
 	}
 
 	refs := map[string]mdStatus{
-		"md3":   {"md3", true, 8, 8, 5853468288, 5853468288},
-		"md127": {"md127", true, 2, 2, 312319552, 312319552},
-		"md0":   {"md0", true, 2, 2, 248896, 248896},
-		"md4":   {"md4", false, 2, 2, 4883648, 4883648},
-		"md6":   {"md6", true, 1, 2, 195310144, 16775552},
-		"md8":   {"md8", true, 2, 2, 195310144, 16775552},
-		"md7":   {"md7", true, 3, 4, 7813735424, 7813735424},
-		"md9":   {"md9", true, 4, 4, 523968, 523968},
+		"md3":   {"md3", true, 8, 8, 5853468288, 5853468288, 0, 0},
+		"md127": {"md127", true, 2, 2, 312319552, 312319552, 0, 0},
+		"md0":   {"md0", true, 2, 2, 248896, 248896, 0, 0},
+		"md4":   {"md4", false, 2, 2, 4883648, 4883648, 0, 0},
+		"md6":   {"md6", true, 1, 2, 195310144, 16775552, 266017792, 1020},
+		"md8":   {"md8", true, 2, 2, 195310144, 16775552, 266017792, 1020},
+		"md7":   {"md7", true, 3, 4, 7813735424, 7813735424, 0, 0},
+		"md9":   {"md9", true, 4, 4, 523968, 523968, 0, 0},
+		"md10":  {"md10", true, 4, 4, 1953260544, 23455232, 68233216, 28956},
+		"md11":  {"md11", true, 2, 2, 976630464, 343781248, 209992704, 3084},
 	}
 
 	for _, md := range mdStates {
@@ -45,3 +48,61 @@ func TestMdadm(t *testing.T) {
 		t.Errorf("expected number of parsed md-device to be %d, but was %d", len(refs), len(mdStates))
 	}
 }
+
+func TestMdadmSysfs(t *testing.T) {
+	for device, want := range map[string]struct {
+		state       string
+		degraded    int64
+		mismatchCnt int64
+		disks       map[string][]string
+	}{
+		"md3":   {"clean", 0, 0, map[string][]string{"sda1": {"in_sync"}, "sdb1": {"in_sync"}, "sdc1": {"in_sync"}, "sdd1": {"in_sync"}, "sde1": {"in_sync"}, "sdf1": {"in_sync"}, "sdg1": {"in_sync"}, "sdh1": {"in_sync"}}},
+		"md127": {"active", 0, 0, map[string][]string{"sdi2": {"in_sync"}, "sdj2": {"in_sync", "write_mostly"}}},
+		"md0":   {"clean", 0, 8, map[string][]string{"sdi1": {"in_sync"}, "sdj1": {"in_sync"}}},
+		"md4":   {"inactive", 0, 0, map[string][]string{}},
+		"md6":   {"recover", 1, 0, map[string][]string{"sda2": {"in_sync"}, "sdb2": {"spare"}}},
+		"md8":   {"resync", 0, 0, map[string][]string{"sda1": {"in_sync"}, "sdb1": {"in_sync"}}},
+		"md7":   {"degraded", 1, 0, map[string][]string{"sdb1": {"in_sync"}, "sdc1": {"faulty"}, "sdd1": {"in_sync"}, "sde1": {"in_sync"}}},
+		"md10":  {"reshape", 0, 0, map[string][]string{"sdc1": {"in_sync"}, "sdd1": {"in_sync"}, "sde1": {"in_sync"}, "sdf1": {"in_sync"}}},
+		"md11":  {"check", 0, 0, map[string][]string{"sdg1": {"in_sync"}, "sdh1": {"in_sync"}}},
+	} {
+		status, err := parseMdSysfs("fixtures/sys/block/" + device + "/md")
+		if err != nil {
+			t.Fatalf("parsing of sysfs state of %s failed: %s", device, err)
+		}
+
+		if got := mdArrayState(status); got != want.state {
+			t.Errorf("want state of %s to be %s, got %s", device, want.state, got)
+		}
+		if status.degraded != want.degraded {
+			t.Errorf("want %d degraded disks on %s, got %d", want.degraded, device, status.degraded)
+		}
+		if status.mismatchCnt != want.mismatchCnt {
+			t.Errorf("want %d mismatched sectors on %s, got %d", want.mismatchCnt, device, status.mismatchCnt)
+		}
+		if !reflect.DeepEqual(status.disks, want.disks) {
+			t.Errorf("want disks of %s to be %v, got %v", device, want.disks, status.disks)
+		}
+	}
+}
+
+func TestMdArrayState(t *testing.T) {
+	for _, c := range []struct {
+		status mdSysfsStatus
+		want   string
+	}{
+		{mdSysfsStatus{arrayState: "clean", syncAction: "idle"}, "clean"},
+		{mdSysfsStatus{arrayState: "write-pending", syncAction: "idle"}, "active"},
+		{mdSysfsStatus{arrayState: "read-auto", syncAction: "idle"}, "readonly"},
+		{mdSysfsStatus{arrayState: "readonly", syncAction: "idle"}, "readonly"},
+		{mdSysfsStatus{arrayState: "clear"}, "unknown"},
+		{mdSysfsStatus{arrayState: "suspended", syncAction: "frozen"}, "unknown"},
+		{mdSysfsStatus{arrayState: "broken", syncAction: "idle"}, "unknown"},
+		{mdSysfsStatus{arrayState: "read-auto", syncAction: "idle", degraded: 1}, "degraded"},
+		{mdSysfsStatus{arrayState: "active", syncAction: "repair"}, "check"},
+	} {
+		if got := mdArrayState(c.status); c.want != got {
+			t.Errorf("want state %s for %+v, got %s", c.want, c.status, got)
+		}
+	}
+}
@@ -33,14 +33,27 @@ const (
 
 var (
 	megacliCommand = flag.String("collector.megacli.command", defaultMegaCli, "Command to run megacli.")
+	storcliCommand = flag.String("collector.megacli.storcli-command", "", "Command to run storcli or perccli. If set, it is used instead of megacli.")
 )
 
 type megaCliCollector struct {
-	cli string
+	cli     string
+	storcli string
 
 	driveTemperature *prometheus.GaugeVec
 	driveCounters    *prometheus.CounterVec
 	drivePresence    *prometheus.GaugeVec
+
+	controllerStatus       *prometheus.GaugeVec
+	controllerAlarm        *prometheus.GaugeVec
+	controllerTemperature  *prometheus.GaugeVec
+	controllerMemoryErrors *prometheus.CounterVec
+	virtualDriveState      *prometheus.GaugeVec
+	virtualDriveCache      *prometheus.GaugeVec
+	physicalDriveState     *prometheus.GaugeVec
+	bbuOptimal             *prometheus.GaugeVec
+	bbuTemperature         *prometheus.GaugeVec
+	bbuCharge              *prometheus.GaugeVec
 }
 
 func init() {
@@ -48,10 +61,11 @@ func init() {
 }
 
 // Takes a prometheus registry and returns a new Collector exposing
-// RAID status through megacli.
+// RAID status through megacli or storcli.
 func NewMegaCliCollector() (Collector, error) {
 	return &megaCliCollector{
-		cli: *megacliCommand,
+		cli:     *megacliCommand,
+		storcli: *storcliCommand,
 		driveTemperature: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: Namespace,
 			Name:      "megacli_drive_temperature_celsius",
@@ -67,10 +81,75 @@ func NewMegaCliCollector() (Collector, error) {
 			Name:      "megacli_adapter_disk_presence",
 			Help:      "megacli: disk presence per adapter",
 		}, []string{"type"}),
+		controllerStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "megacli_controller_optimal",
+			Help:      "storcli: whether the controller status is optimal",
+		}, []string{"controller"}),
+		controllerAlarm: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "megacli_controller_alarm",
+			Help:      "storcli: whether the controller alarm is enabled",
+		}, []string{"controller"}),
+		controllerTemperature: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "megacli_controller_temperature_celsius",
+			Help:      "storcli: controller ROC temperature",
+		}, []string{"controller"}),
+		controllerMemoryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "megacli_controller_memory_errors",
+			Help:      "storcli: controller memory errors",
+		}, []string{"controller", "type"}),
+		virtualDriveState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "megacli_virtual_drive_state",
+			Help:      "storcli: virtual drive state",
+		}, []string{"controller", "drive_group", "virtual_drive", "state"}),
+		virtualDriveCache: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "megacli_virtual_drive_cache_policy",
+			Help:      "storcli: virtual drive read, write and IO cache policy",
+		}, []string{"controller", "drive_group", "virtual_drive", "read", "write", "io"}),
+		physicalDriveState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "megacli_physical_drive_state",
+			Help:      "storcli: physical drive state",
+		}, []string{"controller", "enclosure", "slot", "state"}),
+		bbuOptimal: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "megacli_bbu_optimal",
+			Help:      "storcli: whether the BBU or CacheVault state is optimal",
+		}, []string{"controller", "type"}),
+		bbuTemperature: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "megacli_bbu_temperature_celsius",
+			Help:      "storcli: BBU or CacheVault temperature",
+		}, []string{"controller", "type"}),
+		bbuCharge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "megacli_bbu_charge_ratio",
+			Help:      "storcli: BBU relative state of charge or CacheVault capacitance",
+		}, []string{"controller", "type"}),
 	}, nil
 }
 
 func (c *megaCliCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	if c.storcli != "" {
+		err = c.updateStorcli()
+		c.controllerStatus.Collect(ch)
+		c.controllerAlarm.Collect(ch)
+		c.controllerTemperature.Collect(ch)
+		c.controllerMemoryErrors.Collect(ch)
+		c.virtualDriveState.Collect(ch)
+		c.virtualDriveCache.Collect(ch)
+		c.physicalDriveState.Collect(ch)
+		c.bbuOptimal.Collect(ch)
+		c.bbuTemperature.Collect(ch)
+		c.bbuCharge.Collect(ch)
+		return err
+	}
+
 	err = c.updateAdapter()
 	if err != nil {
 		return err
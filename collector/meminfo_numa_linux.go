@@ -19,6 +19,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -39,6 +40,12 @@ type meminfoKey struct {
 
 type meminfoNumaCollector struct {
 	metricDescs map[string]*prometheus.Desc
+	nodeDesc    *prometheus.Desc
+}
+
+// CPUs and distances to all nodes of a NUMA node.
+type numaNodeInfo struct {
+	node, cpulist, distance string
 }
 
 func init() {
@@ -50,6 +57,11 @@ func init() {
 func NewMeminfoNumaCollector() (Collector, error) {
 	return &meminfoNumaCollector{
 		metricDescs: map[string]*prometheus.Desc{},
+		nodeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, memInfoNumaSubsystem, "node_info"),
+			"CPUs of the NUMA node and its distances to all nodes, in order.",
+			[]string{"node", "cpulist", "distance"}, nil,
+		),
 	}, nil
 }
 
@@ -69,6 +81,30 @@ func (c *meminfoNumaCollector) Update(ch chan<- prometheus.Metric) (err error) {
 		}
 		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, k.numaNode)
 	}
+
+	numaStats, err := getNumaStats()
+	if err != nil {
+		return fmt.Errorf("couldn't get NUMA stats: %s", err)
+	}
+	for k, v := range numaStats {
+		desc, ok := c.metricDescs[k.metricName]
+		if !ok {
+			desc = prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, memInfoNumaSubsystem, k.metricName),
+				fmt.Sprintf("NUMA allocation statistics field %s.", strings.TrimSuffix(k.metricName, "_total")),
+				[]string{"node"}, nil)
+			c.metricDescs[k.metricName] = desc
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, k.numaNode)
+	}
+
+	nodes, err := getNumaNodeInfo()
+	if err != nil {
+		return fmt.Errorf("couldn't get NUMA nodes: %s", err)
+	}
+	for _, n := range nodes {
+		ch <- prometheus.MustNewConstMetric(c.nodeDesc, prometheus.GaugeValue, 1, n.node, n.cpulist, n.distance)
+	}
 	return nil
 }
 
@@ -132,3 +168,79 @@ func parseMemInfoNuma(r io.Reader) (map[meminfoKey]float64, error) {
 
 	return memInfo, nil
 }
+
+func getNumaStats() (map[meminfoKey]float64, error) {
+	stats := make(map[meminfoKey]float64)
+
+	nodes, err := filepath.Glob(sysFilePath("devices/system/node/node[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		file, err := os.Open(path.Join(node, "numastat"))
+		if err != nil {
+			return nil, err
+		}
+		nodeStats, err := parseNumaStat(file, strings.TrimPrefix(path.Base(node), "node"))
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range nodeStats {
+			stats[k] = v
+		}
+	}
+
+	return stats, nil
+}
+
+// Parses the numastat file of a node, with counters like "numa_hit 1234".
+// The counters are in pages.
+func parseNumaStat(r io.Reader, node string) (map[meminfoKey]float64, error) {
+	var (
+		stats   = map[meminfoKey]float64{}
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line in numastat: %s", scanner.Text())
+		}
+		fv, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in numastat: %s", err)
+		}
+		stats[meminfoKey{parts[0] + "_total", node}] = fv
+	}
+
+	return stats, scanner.Err()
+}
+
+func getNumaNodeInfo() ([]numaNodeInfo, error) {
+	nodes, err := filepath.Glob(sysFilePath("devices/system/node/node[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+
+	info := make([]numaNodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		n := numaNodeInfo{node: strings.TrimPrefix(path.Base(node), "node")}
+		for file, value := range map[string]*string{
+			"cpulist":  &n.cpulist,
+			"distance": &n.distance,
+		} {
+			data, err := ioutil.ReadFile(path.Join(node, file))
+			if err != nil {
+				return nil, err
+			}
+			*value = strings.TrimSpace(string(data))
+		}
+		info = append(info, n)
+	}
+
+	return info, nil
+}
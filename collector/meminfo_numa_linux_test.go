@@ -14,6 +14,7 @@
 package collector
 
 import (
+	"flag"
 	"os"
 	"testing"
 )
@@ -57,3 +58,54 @@ func TestMemInfoNuma(t *testing.T) {
 		t.Errorf("want memory FilePages %f, got %f", want, got)
 	}
 }
+
+func TestNumaStat(t *testing.T) {
+	file, err := os.Open("fixtures/sys/devices/system/node/node1/numastat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stats, err := parseNumaStat(file, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 6, len(stats); want != got {
+		t.Errorf("want %d NUMA stats, got %d", want, got)
+	}
+
+	if want, got := 59858626709.0, stats[meminfoKey{"numa_miss_total", "1"}]; want != got {
+		t.Errorf("want numa_miss %f, got %f", want, got)
+	}
+}
+
+func TestNumaNodeInfo(t *testing.T) {
+	defer flag.Set("collector.sysfs", *sysPath)
+	if err := flag.Set("collector.sysfs", "fixtures/sys"); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := getNumaNodeInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(nodes); want != got {
+		t.Fatalf("want %d nodes, got %d", want, got)
+	}
+
+	for _, want := range []numaNodeInfo{
+		{node: "0", cpulist: "0-11,24-35", distance: "10 21"},
+		{node: "1", cpulist: "12-23,36-47", distance: "21 10"},
+	} {
+		var got numaNodeInfo
+		for _, n := range nodes {
+			if n.node == want.node {
+				got = n
+			}
+		}
+		if want != got {
+			t.Errorf("want node %v, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonetclass
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	netClassSubsystem = "network"
+)
+
+var (
+	netClassIgnoredDevices = flag.String(
+		"collector.netclass.ignored-devices", "^$",
+		"Regexp of net devices to ignore for netclass collector.")
+
+	// Numeric attributes of /sys/class/net/<iface>, the speed is in Mbit/s.
+	// Drivers refuse to report some of them, e.g. the speed while the link
+	// is down.
+	netClassFields = []struct {
+		file, name, help string
+		valueType        prometheus.ValueType
+		factor           float64
+	}{
+		{"carrier", "carrier", "Whether the physical link of the network device is up.", prometheus.GaugeValue, 1},
+		{"carrier_changes", "carrier_changes_total", "Number of times the physical link of the network device went up or down.", prometheus.CounterValue, 1},
+		{"speed", "speed_bytes", "Negotiated speed of the network device in bytes per second.", prometheus.GaugeValue, 1000 * 1000 / 8},
+		{"mtu", "mtu_bytes", "Maximum transmission unit of the network device.", prometheus.GaugeValue, 1},
+		{"type", "protocol_type", "Hardware type (ARPHRD_*) of the network device.", prometheus.GaugeValue, 1},
+		{"iflink", "iflink", "Interface index of the device the network device is linked to.", prometheus.GaugeValue, 1},
+		{"tx_queue_len", "transmit_queue_length", "Length of the transmit queue of the network device.", prometheus.GaugeValue, 1},
+		{"dormant", "dormant", "Whether the network device is waiting for an external event, e.g. 802.1X authentication.", prometheus.GaugeValue, 1},
+	}
+)
+
+type netClassDevice struct {
+	name                       string
+	address, operstate, duplex string
+	values                     map[string]float64 // file -> value
+}
+
+type netClassCollector struct {
+	ignoredDevicesPattern *regexp.Regexp
+	fieldDescs            []*prometheus.Desc
+	infoDesc, upDesc      *prometheus.Desc
+}
+
+func init() {
+	Factories["netclass"] = NewNetClassCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// link state, speed and other details of network devices.
+func NewNetClassCollector() (Collector, error) {
+	pattern, err := regexp.Compile(*netClassIgnoredDevices)
+	if err != nil {
+		return nil, fmt.Errorf("invalid netclass ignored devices pattern: %s", err)
+	}
+	fieldDescs := make([]*prometheus.Desc, 0, len(netClassFields))
+	for _, f := range netClassFields {
+		fieldDescs = append(fieldDescs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, netClassSubsystem, f.name),
+			f.help, []string{"device"}, nil,
+		))
+	}
+	return &netClassCollector{
+		ignoredDevicesPattern: pattern,
+		fieldDescs:            fieldDescs,
+		infoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, netClassSubsystem, "info"),
+			"Address, operational state and duplex of the network device.",
+			[]string{"device", "address", "operstate", "duplex"}, nil,
+		),
+		upDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, netClassSubsystem, "up"),
+			"Whether the operational state of the network device is up.",
+			[]string{"device"}, nil,
+		),
+	}, nil
+}
+
+func (c *netClassCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	devices, err := getNetClassDevices(sysFilePath("class/net"))
+	if err != nil {
+		return fmt.Errorf("couldn't get network devices: %s", err)
+	}
+
+	for _, dev := range devices {
+		if c.ignoredDevicesPattern.MatchString(dev.name) {
+			log.Debugf("Ignoring device: %s", dev.name)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, dev.name, dev.address, dev.operstate, dev.duplex)
+		var up float64
+		if dev.operstate == "up" {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, up, dev.name)
+
+		for i, f := range netClassFields {
+			if v, ok := dev.values[f.file]; ok {
+				ch <- prometheus.MustNewConstMetric(c.fieldDescs[i], f.valueType, v, dev.name)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the devices of /sys/class/net, which also contains files like
+// bonding_masters.
+func getNetClassDevices(root string) ([]netClassDevice, error) {
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]netClassDevice, 0, len(files))
+	for _, f := range files {
+		dir := path.Join(root, f.Name())
+		// Devices are symlinks to their directories below /sys/devices.
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		dev, err := readNetClassDevice(dir)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, dev)
+	}
+	return devices, nil
+}
+
+func readNetClassDevice(dir string) (netClassDevice, error) {
+	dev := netClassDevice{
+		name:   path.Base(dir),
+		values: map[string]float64{},
+	}
+
+	for _, f := range netClassFields {
+		value, ok := readNetClassFile(path.Join(dir, f.file))
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return dev, fmt.Errorf("invalid %s of %s: %s", f.file, dev.name, err)
+		}
+		// Drivers report -1 or 4294967295 for an unknown speed.
+		if f.file == "speed" && (v < 0 || v == 1<<32-1) {
+			continue
+		}
+		dev.values[f.file] = v * f.factor
+	}
+
+	for file, value := range map[string]*string{
+		"address":   &dev.address,
+		"operstate": &dev.operstate,
+		"duplex":    &dev.duplex,
+	} {
+		*value, _ = readNetClassFile(path.Join(dir, file))
+	}
+	return dev, nil
+}
+
+// Reads an attribute of a network device, returning false if it doesn't
+// exist or the driver refuses to report it, e.g. with EINVAL.
+func readNetClassFile(name string) (string, bool) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debugf("Ignoring network device attribute %s: %s", name, err)
+		}
+		return "", false
+	}
+	return strings.TrimSpace(string(data)), true
+}
@@ -0,0 +1,60 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestNetClass(t *testing.T) {
+	devices, err := getNetClassDevices("fixtures/sys/class/net")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 6, len(devices); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+
+	byName := map[string]netClassDevice{}
+	for _, dev := range devices {
+		byName[dev.name] = dev
+	}
+
+	eth0 := byName["eth0"]
+	if want, got := 125000000.0, eth0.values["speed"]; want != got {
+		t.Errorf("want speed %f, got %f", want, got)
+	}
+
+	if want, got := "01:01:01:01:01:01", eth0.address; want != got {
+		t.Errorf("want address %s, got %s", want, got)
+	}
+
+	if want, got := "full", eth0.duplex; want != got {
+		t.Errorf("want duplex %s, got %s", want, got)
+	}
+
+	wlan0 := byName["wlan0"]
+	if _, ok := wlan0.values["speed"]; ok {
+		t.Errorf("want no speed for device that is down, got %f", wlan0.values["speed"])
+	}
+
+	if want, got := 13.0, wlan0.values["carrier_changes"]; want != got {
+		t.Errorf("want %f carrier changes, got %f", want, got)
+	}
+
+	if want, got := 772.0, byName["lo"].values["type"]; want != got {
+		t.Errorf("want type %f, got %f", want, got)
+	}
+}
@@ -20,21 +20,25 @@ import (
 	"flag"
 	"fmt"
 	"regexp"
-	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
 )
 
 var (
 	netdevIgnoredDevices = flag.String(
 		"collector.netdev.ignored-devices", "^$",
 		"Regexp of net devices to ignore for netdev collector.")
+	netdevIncludedDevices = flag.String(
+		"collector.netdev.included-devices", "",
+		"Regexp of net devices to include for netdev collector. Empty includes all.")
 )
 
 type netDevCollector struct {
-	subsystem             string
-	ignoredDevicesPattern *regexp.Regexp
-	metricDescs           map[string]*prometheus.Desc
+	subsystem              string
+	ignoredDevicesPattern  *regexp.Regexp
+	includedDevicesPattern *regexp.Regexp
+	metricDescs            map[string]*prometheus.Desc
 }
 
 func init() {
@@ -44,11 +48,19 @@ func init() {
 // NewNetDevCollector returns a new Collector exposing network device stats.
 func NewNetDevCollector() (Collector, error) {
 	pattern := regexp.MustCompile(*netdevIgnoredDevices)
-	return &netDevCollector{
+	c := &netDevCollector{
 		subsystem:             "network",
 		ignoredDevicesPattern: pattern,
 		metricDescs:           map[string]*prometheus.Desc{},
-	}, nil
+	}
+	if *netdevIncludedDevices != "" {
+		re, err := regexp.Compile(*netdevIncludedDevices)
+		if err != nil {
+			return nil, fmt.Errorf("invalid netdev included devices pattern: %s", err)
+		}
+		c.includedDevicesPattern = re
+	}
+	return c, nil
 }
 
 func (c *netDevCollector) Update(ch chan<- prometheus.Metric) (err error) {
@@ -57,6 +69,10 @@ func (c *netDevCollector) Update(ch chan<- prometheus.Metric) (err error) {
 		return fmt.Errorf("couldn't get netstats: %s", err)
 	}
 	for dev, devStats := range netDev {
+		if c.includedDevicesPattern != nil && !c.includedDevicesPattern.MatchString(dev) {
+			log.Debugf("Ignoring device not included: %s", dev)
+			continue
+		}
 		for key, value := range devStats {
 			desc, ok := c.metricDescs[key]
 			if !ok {
@@ -68,11 +84,7 @@ func (c *netDevCollector) Update(ch chan<- prometheus.Metric) (err error) {
 				)
 				c.metricDescs[key] = desc
 			}
-			v, err := strconv.ParseFloat(value, 64)
-			if err != nil {
-				return fmt.Errorf("invalid value %s in netstats: %s", value, err)
-			}
-			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, dev)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, dev)
 		}
 	}
 	return nil
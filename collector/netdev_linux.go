@@ -17,10 +17,12 @@ package collector
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/prometheus/common/log"
@@ -28,9 +30,16 @@ import (
 
 var (
 	procNetDevFieldSep = regexp.MustCompile("[ :] *")
+	netdevNetlink      = flag.Bool(
+		"collector.netdev.netlink", false,
+		"Read network device stats via rtnetlink instead of /proc/net/dev.")
 )
 
-func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error) {
+func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]float64, error) {
+	if *netdevNetlink {
+		return getNetDevNetlinkStats(ignore)
+	}
+
 	file, err := os.Open(procFilePath("net/dev"))
 	if err != nil {
 		return nil, err
@@ -40,7 +49,7 @@ func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error)
 	return parseNetDevStats(file, ignore)
 }
 
-func parseNetDevStats(r io.Reader, ignore *regexp.Regexp) (map[string]map[string]string, error) {
+func parseNetDevStats(r io.Reader, ignore *regexp.Regexp) (map[string]map[string]float64, error) {
 	scanner := bufio.NewScanner(r)
 	scanner.Scan() // skip first header
 	scanner.Scan()
@@ -51,7 +60,7 @@ func parseNetDevStats(r io.Reader, ignore *regexp.Regexp) (map[string]map[string
 	}
 
 	header := strings.Fields(parts[1])
-	netDev := map[string]map[string]string{}
+	netDev := map[string]map[string]float64{}
 	for scanner.Scan() {
 		line := strings.TrimLeft(string(scanner.Text()), " ")
 		parts := procNetDevFieldSep.Split(line, -1)
@@ -64,11 +73,19 @@ func parseNetDevStats(r io.Reader, ignore *regexp.Regexp) (map[string]map[string
 			log.Debugf("Ignoring device: %s", dev)
 			continue
 		}
-		netDev[dev] = map[string]string{}
-		for i, v := range header {
-			netDev[dev]["receive_"+v] = parts[i+1]
-			netDev[dev]["transmit_"+v] = parts[i+1+len(header)]
+		devStats := make(map[string]float64, 2*len(header))
+		for i, value := range parts[1:] {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s in net/dev: %s", value, err)
+			}
+			if i < len(header) {
+				devStats["receive_"+header[i]] = v
+			} else {
+				devStats["transmit_"+header[i-len(header)]] = v
+			}
 		}
+		netDev[dev] = devStats
 	}
-	return netDev, nil
+	return netDev, scanner.Err()
 }
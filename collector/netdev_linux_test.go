@@ -31,16 +31,16 @@ func TestNetDevStats(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if want, got := "10437182923", netStats["wlan0"]["receive_bytes"]; want != got {
-		t.Errorf("want netstat wlan0 bytes %s, got %s", want, got)
+	if want, got := 10437182923.0, netStats["wlan0"]["receive_bytes"]; want != got {
+		t.Errorf("want netstat wlan0 bytes %f, got %f", want, got)
 	}
 
-	if want, got := "68210035552", netStats["eth0"]["receive_bytes"]; want != got {
-		t.Errorf("want netstat eth0 bytes %s, got %s", want, got)
+	if want, got := 68210035552.0, netStats["eth0"]["receive_bytes"]; want != got {
+		t.Errorf("want netstat eth0 bytes %f, got %f", want, got)
 	}
 
-	if want, got := "934", netStats["tun0"]["transmit_packets"]; want != got {
-		t.Errorf("want netstat tun0 packets %s, got %s", want, got)
+	if want, got := 934.0, netStats["tun0"]["transmit_packets"]; want != got {
+		t.Errorf("want netstat tun0 packets %f, got %f", want, got)
 	}
 
 	if want, got := 6, len(netStats); want != got {
@@ -0,0 +1,152 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonetdev
+
+package collector
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"syscall"
+
+	"github.com/prometheus/common/log"
+)
+
+const (
+	// IFLA_STATS64 is missing from the syscall package, IFLA_STATS holds
+	// the same counters truncated to 32 bits.
+	iflaStats64 = 23
+
+	// Number of counters of rtnl_link_stats64 up to tx_compressed, newer
+	// kernels append more.
+	netlinkLinkStatsLen = 23
+)
+
+var (
+	// Counters of /proc/net/dev by the indexes of the rtnl_link_stats64 or
+	// rtnl_link_stats fields they are made up of, as summed up by
+	// dev_seq_printf_stats() in net/core/net-procfs.c. parseNetDevStats
+	// names the transmit columns "colls carrier compressed" after the
+	// receive ones "frame compressed multicast", so collisions, carrier
+	// errors and compressed packets are exported as transmit_frame,
+	// transmit_compressed and transmit_multicast.
+	netlinkLinkStatsFields = []struct {
+		name    string
+		indexes []int
+	}{
+		{"receive_bytes", []int{2}},
+		{"receive_packets", []int{0}},
+		{"receive_errs", []int{4}},
+		{"receive_drop", []int{6, 15}},
+		{"receive_fifo", []int{14}},
+		{"receive_frame", []int{10, 11, 12, 13}},
+		{"receive_compressed", []int{21}},
+		{"receive_multicast", []int{8}},
+		{"transmit_bytes", []int{3}},
+		{"transmit_packets", []int{1}},
+		{"transmit_errs", []int{5}},
+		{"transmit_drop", []int{7}},
+		{"transmit_fifo", []int{18}},
+		{"transmit_frame", []int{9}},
+		{"transmit_compressed", []int{17, 16, 20, 19}},
+		{"transmit_multicast", []int{22}},
+	}
+)
+
+// Dumps the links of the network namespace of the process with a
+// RTM_GETLINK request, which doesn't need any privileges.
+func getNetDevNetlinkStats(ignore *regexp.Regexp) (map[string]map[string]float64, error) {
+	tab, err := syscall.NetlinkRIB(syscall.RTM_GETLINK, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, os.NewSyscallError("netlinkrib", err)
+	}
+	msgs, err := syscall.ParseNetlinkMessage(tab)
+	if err != nil {
+		return nil, os.NewSyscallError("parsenetlinkmessage", err)
+	}
+
+	return parseNetDevNetlinkStats(msgs, ignore)
+}
+
+func parseNetDevNetlinkStats(msgs []syscall.NetlinkMessage, ignore *regexp.Regexp) (map[string]map[string]float64, error) {
+	var (
+		netDev = map[string]map[string]float64{}
+		order  = nativeEndian()
+	)
+
+	for i := range msgs {
+		switch msgs[i].Header.Type {
+		case syscall.NLMSG_DONE:
+			return netDev, nil
+		case syscall.RTM_NEWLINK:
+		default:
+			continue
+		}
+
+		attrs, err := syscall.ParseNetlinkRouteAttr(&msgs[i])
+		if err != nil {
+			return nil, os.NewSyscallError("parsenetlinkrouteattr", err)
+		}
+
+		var (
+			dev   string
+			stats []uint64
+		)
+		for _, a := range attrs {
+			switch a.Attr.Type {
+			case syscall.IFLA_IFNAME:
+				dev = string(bytes.TrimRight(a.Value, "\x00"))
+			case iflaStats64:
+				if len(a.Value) < 8*netlinkLinkStatsLen {
+					return nil, fmt.Errorf("invalid IFLA_STATS64 length %d", len(a.Value))
+				}
+				stats = make([]uint64, netlinkLinkStatsLen)
+				for j := range stats {
+					stats[j] = order.Uint64(a.Value[8*j:])
+				}
+			case syscall.IFLA_STATS:
+				if stats != nil {
+					continue
+				}
+				if len(a.Value) < 4*netlinkLinkStatsLen {
+					return nil, fmt.Errorf("invalid IFLA_STATS length %d", len(a.Value))
+				}
+				stats = make([]uint64, netlinkLinkStatsLen)
+				for j := range stats {
+					stats[j] = uint64(order.Uint32(a.Value[4*j:]))
+				}
+			}
+		}
+		if dev == "" || stats == nil {
+			continue
+		}
+		if ignore.MatchString(dev) {
+			log.Debugf("Ignoring device: %s", dev)
+			continue
+		}
+
+		devStats := make(map[string]float64, len(netlinkLinkStatsFields))
+		for _, f := range netlinkLinkStatsFields {
+			var v uint64
+			for _, j := range f.indexes {
+				v += stats[j]
+			}
+			devStats[f.name] = float64(v)
+		}
+		netDev[dev] = devStats
+	}
+	return netDev, nil
+}
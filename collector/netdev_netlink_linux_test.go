@@ -0,0 +1,319 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"syscall"
+	"testing"
+)
+
+// Returns a RTM_NEWLINK message with the given name and link stats, the
+// counters are encoded as u64 for IFLA_STATS64 and as u32 for IFLA_STATS.
+func netlinkLinkMessage(name string, stats64, stats32 []uint64) syscall.NetlinkMessage {
+	order := nativeEndian()
+	data := make([]byte, syscall.SizeofIfInfomsg)
+	addAttr := func(typ uint16, value []byte) {
+		attr := make([]byte, syscall.SizeofRtAttr, syscall.SizeofRtAttr+len(value)+3)
+		order.PutUint16(attr[0:], uint16(syscall.SizeofRtAttr+len(value)))
+		order.PutUint16(attr[2:], typ)
+		attr = append(attr, value...)
+		for len(attr)%4 != 0 {
+			attr = append(attr, 0)
+		}
+		data = append(data, attr...)
+	}
+
+	addAttr(syscall.IFLA_IFNAME, append([]byte(name), 0))
+	if stats32 != nil {
+		value := make([]byte, 4*len(stats32))
+		for i, v := range stats32 {
+			order.PutUint32(value[4*i:], uint32(v))
+		}
+		addAttr(syscall.IFLA_STATS, value)
+	}
+	if stats64 != nil {
+		value := make([]byte, 8*len(stats64))
+		for i, v := range stats64 {
+			order.PutUint64(value[8*i:], v)
+		}
+		addAttr(iflaStats64, value)
+	}
+
+	return syscall.NetlinkMessage{
+		Header: syscall.NlMsghdr{
+			Len:  uint32(syscall.SizeofNlMsghdr + len(data)),
+			Type: syscall.RTM_NEWLINK,
+		},
+		Data: data,
+	}
+}
+
+func TestNetDevNetlinkStats(t *testing.T) {
+	stats := func(base uint64) []uint64 {
+		s := make([]uint64, netlinkLinkStatsLen+1) // with rx_nohandler
+		for i := range s {
+			s[i] = base + uint64(i)
+		}
+		return s
+	}
+
+	msgs := []syscall.NetlinkMessage{
+		netlinkLinkMessage("eth0", stats(1<<40), stats(100)),
+		netlinkLinkMessage("wlan0", nil, stats(100)),
+		netlinkLinkMessage("veth4B09XN", stats(0), nil),
+		{Header: syscall.NlMsghdr{Type: syscall.NLMSG_DONE}},
+		netlinkLinkMessage("tun0", stats(0), nil),
+	}
+
+	netStats, err := parseNetDevNetlinkStats(msgs, regexp.MustCompile("^veth"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 2, len(netStats); want != got {
+		t.Fatalf("want count of devices to be %d, got %d", want, got)
+	}
+
+	if want, got := float64(1<<40+2), netStats["eth0"]["receive_bytes"]; want != got {
+		t.Errorf("want eth0 receive bytes %f, got %f", want, got)
+	}
+
+	// rx_dropped and rx_missed_errors.
+	if want, got := float64(2*100+6+15), netStats["wlan0"]["receive_drop"]; want != got {
+		t.Errorf("want wlan0 receive drop %f, got %f", want, got)
+	}
+
+	// rx_length_errors, rx_over_errors, rx_crc_errors and rx_frame_errors.
+	if want, got := float64(4*100+10+11+12+13), netStats["wlan0"]["receive_frame"]; want != got {
+		t.Errorf("want wlan0 receive frame %f, got %f", want, got)
+	}
+
+	// tx_compressed, see netlinkLinkStatsFields.
+	if want, got := float64(1<<40+22), netStats["eth0"]["transmit_multicast"]; want != got {
+		t.Errorf("want eth0 transmit compressed %f, got %f", want, got)
+	}
+
+	if want, got := len(netlinkLinkStatsFields), len(netStats["eth0"]); want != got {
+		t.Errorf("want %d eth0 stats, got %d", want, got)
+	}
+}
+
+func TestNetDevNetlinkStatsShort(t *testing.T) {
+	msgs := []syscall.NetlinkMessage{
+		netlinkLinkMessage("eth0", make([]uint64, netlinkLinkStatsLen-1), nil),
+	}
+	if _, err := parseNetDevNetlinkStats(msgs, regexp.MustCompile("^$")); err == nil {
+		t.Error("want error for short IFLA_STATS64, got none")
+	}
+}
+
+// Formats link stats like dev_seq_printf_stats() in net/core/net-procfs.c.
+func procNetDevLine(dev string, s []uint64) string {
+	return fmt.Sprintf("%6s:%8d %7d %4d %4d %4d %5d %10d %9d %8d %7d %4d %4d %4d %5d %7d %10d\n",
+		dev, s[2], s[0], s[4], s[6]+s[15], s[14], s[10]+s[11]+s[12]+s[13], s[21], s[8],
+		s[3], s[1], s[5], s[7], s[18], s[9], s[17]+s[16]+s[20]+s[19], s[22])
+}
+
+// Feeds the same link stats to both backends.
+func TestNetDevNetlinkMatchesProcfsFixture(t *testing.T) {
+	file, err := os.Open("fixtures/proc/net/dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var (
+		procNetDev bytes.Buffer
+		msgs       []syscall.NetlinkMessage
+		scanner    = bufio.NewScanner(file)
+	)
+	for i := 0; i < 2 && scanner.Scan(); i++ {
+		procNetDev.WriteString(scanner.Text() + "\n")
+	}
+	for d, dev := range []string{"eth0", "wlan0", "lo"} {
+		stats := make([]uint64, netlinkLinkStatsLen)
+		for i := range stats {
+			stats[i] = uint64(1000*(d+1) + 37*i)
+		}
+		procNetDev.WriteString(procNetDevLine(dev, stats))
+		msgs = append(msgs, netlinkLinkMessage(dev, stats, nil))
+	}
+
+	ignore := regexp.MustCompile("^$")
+	procStats, err := parseNetDevStats(&procNetDev, ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	netlinkStats, err := parseNetDevNetlinkStats(msgs, ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 3, len(netlinkStats); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+	for dev, devStats := range procStats {
+		if want, got := len(devStats), len(netlinkStats[dev]); want != got {
+			t.Errorf("want %d stats of %s, got %d", want, dev, got)
+		}
+		for key, want := range devStats {
+			if got := netlinkStats[dev][key]; want != got {
+				t.Errorf("want %s %s %f, got %f", dev, key, want, got)
+			}
+		}
+	}
+}
+
+// Set in the child process of TestNetDevNetlinkMatchesProcfs, which runs
+// in a network namespace of its own.
+const netDevNetnsEnv = "NODE_EXPORTER_NETDEV_NETNS"
+
+// Compares both backends on devices with traffic in a new network
+// namespace. Needs root or unprivileged user namespaces and iproute2.
+func TestNetDevNetlinkMatchesProcfs(t *testing.T) {
+	if os.Getenv(netDevNetnsEnv) == "" {
+		runNetDevNetns(t)
+		return
+	}
+	setupNetDevNetns(t)
+
+	ignore := regexp.MustCompile("^$")
+	file, err := os.Open("/proc/net/dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	procStats, err := parseNetDevStats(file, ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	netlinkStats, err := getNetDevNetlinkStats(ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Carrier errors and collisions, see netlinkLinkStatsFields.
+	for dev, key := range map[string]string{"vx0": "transmit_compressed", "vx1": "transmit_frame"} {
+		if procStats[dev][key] == 0 {
+			t.Fatalf("want %s %s to be counted, got %v", dev, key, procStats[dev])
+		}
+	}
+	if procStats["lo"]["receive_packets"] == 0 {
+		t.Fatalf("want traffic on lo, got %v", procStats["lo"])
+	}
+
+	if want, got := len(procStats), len(netlinkStats); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+	for dev, devStats := range procStats {
+		for key, want := range devStats {
+			// The counters may only have grown since /proc/net/dev was read.
+			if got, ok := netlinkStats[dev][key]; !ok || got < want {
+				t.Errorf("want %s %s of at least %f, got %f", dev, key, want, got)
+			}
+		}
+	}
+}
+
+// Runs TestNetDevNetlinkMatchesProcfs again in a new network namespace.
+func runNetDevNetns(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestNetDevNetlinkMatchesProcfs$", "-test.v")
+	cmd.Env = append(os.Environ(), netDevNetnsEnv+"=1")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWNET}
+	if os.Getuid() != 0 {
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil && cmd.ProcessState == nil {
+		t.Skipf("couldn't create network namespace: %s", err)
+	}
+	if err != nil {
+		t.Fatalf("test in network namespace failed: %s\n%s", err, out)
+	}
+	if bytes.Contains(out, []byte("--- SKIP")) {
+		t.Skipf("test in network namespace skipped:\n%s", out)
+	}
+}
+
+// Creates devices in the network namespace and sends traffic over them.
+// vxlan counts carrier errors without a route to the remote and
+// collisions if the route to the remote goes over the device itself.
+func setupNetDevNetns(t *testing.T) {
+	if _, err := exec.LookPath("ip"); err != nil {
+		t.Skip(err)
+	}
+	for _, args := range [][]string{
+		{"link", "set", "lo", "up"},
+		{"link", "add", "vx0", "type", "vxlan", "id", "42", "remote", "192.0.2.1", "dstport", "4789"},
+		{"link", "set", "vx0", "up"},
+		{"address", "add", "10.42.0.1/24", "dev", "vx0"},
+		{"neighbor", "add", "10.42.0.2", "lladdr", "02:00:00:00:00:02", "dev", "vx0"},
+		{"link", "add", "vx1", "type", "vxlan", "id", "43", "remote", "198.51.100.1", "dstport", "4789"},
+		{"link", "set", "vx1", "up"},
+		{"address", "add", "10.43.0.1/24", "dev", "vx1"},
+		{"neighbor", "add", "10.43.0.2", "lladdr", "02:00:00:00:00:03", "dev", "vx1"},
+		{"route", "add", "198.51.100.0/24", "dev", "vx1"},
+	} {
+		if out, err := exec.Command("ip", args...).CombinedOutput(); err != nil {
+			t.Skipf("couldn't run ip %v: %s\n%s", args, err, out)
+		}
+	}
+
+	for _, addr := range []string{"10.42.0.2:9", "10.43.0.2:9", "127.0.0.1:9"} {
+		conn, err := net.Dial("udp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			// Writes fail once the port unreachable of lo came back.
+			conn.Write([]byte("node_exporter"))
+		}
+		conn.Close()
+	}
+}
+
+func BenchmarkNetDevProcfs(b *testing.B) {
+	ignore := regexp.MustCompile("^$")
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open("/proc/net/dev")
+		if err != nil {
+			b.Skip(err)
+		}
+		_, err = parseNetDevStats(file, ignore)
+		file.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNetDevNetlink(b *testing.B) {
+	ignore := regexp.MustCompile("^$")
+	for i := 0; i < b.N; i++ {
+		if _, err := getNetDevNetlinkStats(ignore); err != nil {
+			b.Skip(err)
+		}
+	}
+}
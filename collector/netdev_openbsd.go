@@ -18,7 +18,6 @@ package collector
 import (
 	"errors"
 	"regexp"
-	"strconv"
 
 	"github.com/prometheus/common/log"
 )
@@ -31,8 +30,8 @@ import (
 */
 import "C"
 
-func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error) {
-	netDev := map[string]map[string]string{}
+func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]float64, error) {
+	netDev := map[string]map[string]float64{}
 
 	var ifap, ifa *C.struct_ifaddrs
 	if C.getifaddrs(&ifap) == -1 {
@@ -48,18 +47,18 @@ func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error)
 				continue
 			}
 
-			devStats := map[string]string{}
+			devStats := map[string]float64{}
 			data := (*C.struct_if_data)(ifa.ifa_data)
 
-			devStats["receive_packets"] = strconv.Itoa(int(data.ifi_ipackets))
-			devStats["transmit_packets"] = strconv.Itoa(int(data.ifi_opackets))
-			devStats["receive_errs"] = strconv.Itoa(int(data.ifi_ierrors))
-			devStats["transmit_errs"] = strconv.Itoa(int(data.ifi_oerrors))
-			devStats["receive_bytes"] = strconv.Itoa(int(data.ifi_ibytes))
-			devStats["transmit_bytes"] = strconv.Itoa(int(data.ifi_obytes))
-			devStats["receive_multicast"] = strconv.Itoa(int(data.ifi_imcasts))
-			devStats["transmit_multicast"] = strconv.Itoa(int(data.ifi_omcasts))
-			devStats["receive_drop"] = strconv.Itoa(int(data.ifi_iqdrops))
+			devStats["receive_packets"] = float64(data.ifi_ipackets)
+			devStats["transmit_packets"] = float64(data.ifi_opackets)
+			devStats["receive_errs"] = float64(data.ifi_ierrors)
+			devStats["transmit_errs"] = float64(data.ifi_oerrors)
+			devStats["receive_bytes"] = float64(data.ifi_ibytes)
+			devStats["transmit_bytes"] = float64(data.ifi_obytes)
+			devStats["receive_multicast"] = float64(data.ifi_imcasts)
+			devStats["transmit_multicast"] = float64(data.ifi_omcasts)
+			devStats["receive_drop"] = float64(data.ifi_iqdrops)
 			netDev[dev] = devStats
 		}
 	}
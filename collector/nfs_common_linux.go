@@ -0,0 +1,69 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonfs !nonfsd
+
+package collector
+
+import (
+	"fmt"
+	"strconv"
+)
+
+var (
+	// Procedures of the proc2, proc3 and proc4 lines of /proc/net/rpc/nfs,
+	// in the order of their values. The server uses the same procedures for
+	// NFSv2 and NFSv3 in /proc/net/rpc/nfsd. See nfs2proc.c, nfs3proc.c and
+	// the NFSPROC4_CLNT_* enum in include/linux/nfs4.h of the kernel sources.
+	nfsProcedures = map[string][]string{
+		"2": {
+			"null", "getattr", "setattr", "root", "lookup", "readlink", "read",
+			"writecache", "write", "create", "remove", "rename", "link",
+			"symlink", "mkdir", "rmdir", "readdir", "statfs",
+		},
+		"3": {
+			"null", "getattr", "setattr", "lookup", "access", "readlink",
+			"read", "write", "create", "mkdir", "symlink", "mknod", "remove",
+			"rmdir", "rename", "link", "readdir", "readdirplus", "fsstat",
+			"fsinfo", "pathconf", "commit",
+		},
+		"4": {
+			"null", "read", "write", "commit", "open", "open_confirm",
+			"open_noattr", "open_downgrade", "close", "setattr", "fsinfo",
+			"renew", "setclientid", "setclientid_confirm", "lock", "lockt",
+			"locku", "access", "getattr", "lookup", "lookup_root", "remove",
+			"rename", "link", "symlink", "create", "pathconf", "statfs",
+			"readlink", "readdir", "server_caps", "delegreturn", "getacl",
+			"setacl", "fs_locations", "release_lockowner", "secinfo",
+			"fsid_present", "exchange_id", "create_session",
+			"destroy_session", "sequence", "get_lease_time",
+			"reclaim_complete", "layoutget", "getdeviceinfo", "layoutcommit",
+			"layoutreturn", "secinfo_no_name", "test_stateid", "free_stateid",
+			"getdevicelist", "bind_conn_to_session", "destroy_clientid",
+			"seek", "allocate", "deallocate", "layoutstats", "clone", "copy",
+			"offload_cancel", "lookupp", "layouterror",
+		},
+	}
+)
+
+func parseNfsValues(fields []string) ([]float64, error) {
+	values := make([]float64, 0, len(fields))
+	for _, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s in NFS stats: %s", f, err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonfs
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	nfsSubsystem = "nfs"
+)
+
+var (
+	// Per operation statistics of /proc/self/mountstats, in the order of
+	// their values. Times are in milliseconds.
+	nfsMountOperationFields = []struct {
+		name, help string
+		divisor    float64
+	}{
+		{"requests_total", "Number of requests of the operation.", 1},
+		{"transmissions_total", "Number of times requests of the operation were transmitted.", 1},
+		{"major_timeouts_total", "Number of times requests of the operation had a major timeout.", 1},
+		{"sent_bytes_total", "Number of bytes sent for the operation, including RPC headers.", 1},
+		{"received_bytes_total", "Number of bytes received for the operation, including RPC headers.", 1},
+		{"queue_time_seconds_total", "Time requests of the operation spent queued before transmission.", 1000},
+		{"response_time_seconds_total", "Time spent waiting for responses to requests of the operation.", 1000},
+		{"request_time_seconds_total", "Time requests of the operation took from queueing to completion.", 1000},
+	}
+)
+
+// Statistics of the NFS client from /proc/net/rpc/nfs.
+type nfsClientStats struct {
+	netUDP, netTCP, netTCPConnections float64
+	rpcCalls, rpcRetransmissions      float64
+	rpcAuthRefreshes                  float64
+	procedures                        map[string][]float64 // version -> counts
+}
+
+// Statistics of an NFS mount from /proc/self/mountstats.
+type nfsMountStats struct {
+	export, mountPoint string
+	operations         map[string][]float64 // operation -> values
+}
+
+type nfsCollector struct {
+	packetsDesc, connectionsDesc, rpcsDesc, rpcRetransmissionsDesc,
+	rpcAuthRefreshesDesc, requestsDesc *prometheus.Desc
+	mountOperationDescs []*prometheus.Desc
+}
+
+func init() {
+	Factories["nfs"] = NewNfsCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// NFS client statistics.
+func NewNfsCollector() (Collector, error) {
+	mountOperationDescs := make([]*prometheus.Desc, 0, len(nfsMountOperationFields))
+	for _, f := range nfsMountOperationFields {
+		mountOperationDescs = append(mountOperationDescs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsSubsystem, "mount_operation_"+f.name),
+			f.help, []string{"export", "mountpoint", "operation"}, nil,
+		))
+	}
+	return &nfsCollector{
+		packetsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsSubsystem, "packets_total"),
+			"Number of NFS packets received by the client by protocol.",
+			[]string{"protocol"}, nil,
+		),
+		connectionsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsSubsystem, "connections_total"),
+			"Number of TCP connections established by the NFS client.",
+			nil, nil,
+		),
+		rpcsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsSubsystem, "rpcs_total"),
+			"Number of RPC calls made by the NFS client.",
+			nil, nil,
+		),
+		rpcRetransmissionsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsSubsystem, "rpc_retransmissions_total"),
+			"Number of RPC calls retransmitted by the NFS client.",
+			nil, nil,
+		),
+		rpcAuthRefreshesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsSubsystem, "rpc_authentication_refreshes_total"),
+			"Number of RPC authentication refreshes of the NFS client.",
+			nil, nil,
+		),
+		requestsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsSubsystem, "requests_total"),
+			"Number of NFS procedures called by the client by protocol version and procedure.",
+			[]string{"version", "procedure"}, nil,
+		),
+		mountOperationDescs: mountOperationDescs,
+	}, nil
+}
+
+func (c *nfsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	stats, err := readNfsClientStats(procFilePath("net/rpc/nfs"))
+	if os.IsNotExist(err) {
+		log.Debugf("Not collecting NFS client stats, NFS module not loaded")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't get NFS client stats: %s", err)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.packetsDesc, prometheus.CounterValue, stats.netUDP, "udp")
+	ch <- prometheus.MustNewConstMetric(c.packetsDesc, prometheus.CounterValue, stats.netTCP, "tcp")
+	ch <- prometheus.MustNewConstMetric(c.connectionsDesc, prometheus.CounterValue, stats.netTCPConnections)
+	ch <- prometheus.MustNewConstMetric(c.rpcsDesc, prometheus.CounterValue, stats.rpcCalls)
+	ch <- prometheus.MustNewConstMetric(c.rpcRetransmissionsDesc, prometheus.CounterValue, stats.rpcRetransmissions)
+	ch <- prometheus.MustNewConstMetric(c.rpcAuthRefreshesDesc, prometheus.CounterValue, stats.rpcAuthRefreshes)
+	for version, counts := range stats.procedures {
+		names := nfsProcedures[version]
+		for i, v := range counts {
+			// Newer kernels may know procedures we don't, use their number.
+			procedure := strconv.Itoa(i)
+			if i < len(names) {
+				procedure = names[i]
+			}
+			ch <- prometheus.MustNewConstMetric(c.requestsDesc, prometheus.CounterValue, v, version, procedure)
+		}
+	}
+
+	mounts, err := readNfsMountStats(procFilePath("self/mountstats"))
+	if err != nil {
+		return fmt.Errorf("couldn't get NFS mount stats: %s", err)
+	}
+	for _, m := range mounts {
+		for op, values := range m.operations {
+			for i, v := range values {
+				// Kernels with statistics version 1.1 also count errors,
+				// which older ones don't provide.
+				if i >= len(nfsMountOperationFields) {
+					break
+				}
+				ch <- prometheus.MustNewConstMetric(c.mountOperationDescs[i], prometheus.CounterValue,
+					v/nfsMountOperationFields[i].divisor, m.export, m.mountPoint, op)
+			}
+		}
+	}
+	return nil
+}
+
+func readNfsClientStats(name string) (nfsClientStats, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nfsClientStats{}, err
+	}
+	defer file.Close()
+
+	return parseNfsClientStats(file)
+}
+
+func parseNfsClientStats(r io.Reader) (nfsClientStats, error) {
+	var (
+		stats   = nfsClientStats{procedures: map[string][]float64{}}
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 {
+			continue
+		}
+		values, err := parseNfsValues(parts[1:])
+		if err != nil {
+			return stats, err
+		}
+
+		switch key := parts[0]; {
+		case key == "net":
+			if len(values) < 4 {
+				return stats, fmt.Errorf("invalid net line in NFS stats: %s", scanner.Text())
+			}
+			stats.netUDP, stats.netTCP, stats.netTCPConnections = values[1], values[2], values[3]
+		case key == "rpc":
+			if len(values) < 3 {
+				return stats, fmt.Errorf("invalid rpc line in NFS stats: %s", scanner.Text())
+			}
+			stats.rpcCalls, stats.rpcRetransmissions, stats.rpcAuthRefreshes = values[0], values[1], values[2]
+		case strings.HasPrefix(key, "proc"):
+			// The first value is the number of procedures that follow.
+			if int(values[0]) != len(values)-1 {
+				return stats, fmt.Errorf("invalid %s line in NFS stats: %s", key, scanner.Text())
+			}
+			stats.procedures[strings.TrimPrefix(key, "proc")] = values[1:]
+		}
+	}
+	return stats, scanner.Err()
+}
+
+func readNfsMountStats(name string) ([]nfsMountStats, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseNfsMountStats(file)
+}
+
+// Parses /proc/self/mountstats and returns the per operation statistics of
+// all NFS mounts. Each mount starts with a line like
+// "device <export> mounted on <mountpoint> with fstype <type> ...".
+func parseNfsMountStats(r io.Reader) ([]nfsMountStats, error) {
+	var (
+		mounts  []nfsMountStats
+		current *nfsMountStats
+		perOp   bool
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+
+		if parts[0] == "device" {
+			if current != nil {
+				mounts = append(mounts, *current)
+			}
+			current, perOp = nil, false
+			if len(parts) < 8 || parts[2] != "mounted" || parts[3] != "on" || parts[6] != "fstype" {
+				return nil, fmt.Errorf("invalid device line in mountstats: %s", scanner.Text())
+			}
+			if parts[7] != "nfs" && parts[7] != "nfs4" {
+				continue
+			}
+			current = &nfsMountStats{
+				export:     parts[1],
+				mountPoint: parts[4],
+				operations: map[string][]float64{},
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if parts[0] == "per-op" {
+			perOp = true
+			continue
+		}
+		if !perOp || !strings.HasSuffix(parts[0], ":") {
+			continue
+		}
+		values, err := parseNfsValues(parts[1:])
+		if err != nil {
+			return nil, err
+		}
+		current.operations[strings.ToLower(strings.TrimSuffix(parts[0], ":"))] = values
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		mounts = append(mounts, *current)
+	}
+	return mounts, nil
+}
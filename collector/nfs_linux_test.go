@@ -0,0 +1,86 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNfsClientStats(t *testing.T) {
+	stats, err := readNfsClientStats("fixtures/proc/net/rpc/nfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 18628.0, stats.netTCP; want != got {
+		t.Errorf("want %f tcp packets, got %f", want, got)
+	}
+
+	if want, got := 4.0, stats.rpcRetransmissions; want != got {
+		t.Errorf("want %f retransmissions, got %f", want, got)
+	}
+
+	if want, got := 22, len(stats.procedures["3"]); want != got {
+		t.Fatalf("want %d v3 procedures, got %d", want, got)
+	}
+
+	if want, got := 4084749.0, stats.procedures["3"][1]; want != got {
+		t.Errorf("want %f v3 getattr calls, got %f", want, got)
+	}
+
+	if want, got := 31977.0, stats.procedures["4"][18]; want != got {
+		t.Errorf("want %f v4 getattr calls, got %f", want, got)
+	}
+
+	if _, err := parseNfsClientStats(strings.NewReader("proc3 22 1 2 3\n")); err == nil {
+		t.Error("want error for truncated procedure line")
+	}
+}
+
+func TestNfsMountStats(t *testing.T) {
+	mounts, err := readNfsMountStats("fixtures/proc/self/mountstats")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 2, len(mounts); want != got {
+		t.Fatalf("want %d mounts, got %d", want, got)
+	}
+
+	if want, got := "192.168.1.1:/srv/home", mounts[0].export; want != got {
+		t.Errorf("want export %s, got %s", want, got)
+	}
+
+	if want, got := "/var/cache/build", mounts[1].mountPoint; want != got {
+		t.Errorf("want mountpoint %s, got %s", want, got)
+	}
+
+	if want, got := 9, len(mounts[0].operations["lookup"]); want != got {
+		t.Errorf("want %d values for lookup, got %d", want, got)
+	}
+
+	write := mounts[1].operations["write"]
+	if want, got := 8, len(write); want != got {
+		t.Fatalf("want %d values for write, got %d", want, got)
+	}
+
+	if want, got := 235178856.0, write[3]; want != got {
+		t.Errorf("want %f bytes sent for write, got %f", want, got)
+	}
+
+	if want, got := 70747.0, write[7]; want != got {
+		t.Errorf("want %f ms execute time for write, got %f", want, got)
+	}
+}
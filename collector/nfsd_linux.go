@@ -0,0 +1,337 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonfsd
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	nfsdSubsystem = "nfsd"
+)
+
+var (
+	// Procedures of the proc4 line, NFSv4 bundles all operations into
+	// compound requests.
+	nfsdV4Procedures = []string{"null", "compound"}
+
+	// Operations of the proc4ops line, in the order of their values. See
+	// enum nfs_opnum4 in include/linux/nfs4.h of the kernel sources, the
+	// values 0 to 2 are unused. Older kernels only know the operations of
+	// NFSv4.0 or NFSv4.1 and provide fewer values.
+	nfsdV4Operations = []string{
+		"", "", "", "access", "close", "commit", "create", "delegpurge",
+		"delegreturn", "getattr", "getfh", "link", "lock", "lockt", "locku",
+		"lookup", "lookupp", "nverify", "open", "openattr", "open_confirm",
+		"open_downgrade", "putfh", "putpubfh", "putrootfh", "read",
+		"readdir", "readlink", "remove", "rename", "renew", "restorefh",
+		"savefh", "secinfo", "setattr", "setclientid",
+		"setclientid_confirm", "verify", "write", "release_lockowner",
+		"backchannel_ctl", "bind_conn_to_session", "exchange_id",
+		"create_session", "destroy_session", "free_stateid",
+		"get_dir_delegation", "getdeviceinfo", "getdevicelist",
+		"layoutcommit", "layoutget", "layoutreturn", "secinfo_no_name",
+		"sequence", "set_ssv", "test_stateid", "want_delegation",
+		"destroy_clientid", "reclaim_complete", "allocate", "copy",
+		"copy_notify", "deallocate", "io_advise", "layouterror",
+		"layoutstats", "offload_cancel", "offload_status", "read_plus",
+		"seek", "write_same", "clone", "getxattr", "setxattr", "listxattrs",
+		"removexattr",
+	}
+
+	// Buckets of the thread usage and read-ahead cache depth histograms of
+	// the th and ra lines, in percent.
+	nfsdHistogramBuckets = []string{"0-10", "10-20", "20-30", "30-40", "40-50", "50-60", "60-70", "70-80", "80-90", "90-100"}
+)
+
+// Statistics of the NFS server from /proc/net/rpc/nfsd, one field per line.
+type nfsdStats struct {
+	// rc: reply cache.
+	replyCache struct {
+		hits, misses, noCache float64
+	}
+	// fh: file handles, only the first value is still maintained.
+	staleFileHandles float64
+	// io: bytes read from and written to disk.
+	io struct {
+		read, written float64
+	}
+	// th: server threads.
+	threads struct {
+		count, fullCount float64
+		usage            []float64 // seconds per usage bucket
+	}
+	// ra: read-ahead cache, removed in Linux 5.8.
+	hasReadAhead bool
+	readAhead    struct {
+		size     float64
+		depths   []float64 // hits per depth bucket
+		notFound float64
+	}
+	// net: network packets.
+	net struct {
+		udp, tcp, tcpConnections float64
+	}
+	// rpc: RPC calls, the number of bad calls is the sum of the errors.
+	rpc struct {
+		calls, badFormat, badAuth, badClient float64
+	}
+	// proc2, proc3 and proc4: calls per procedure by version.
+	procedures map[string][]float64
+	// proc4ops: NFSv4 operations in compound requests.
+	v4Operations []float64
+}
+
+type nfsdCollector struct {
+	replyCacheDesc, staleFileHandlesDesc, diskBytesReadDesc,
+	diskBytesWrittenDesc, threadsDesc, threadsFullDesc, threadUsageDesc,
+	readAheadSizeDesc, readAheadHitsDesc, readAheadNotFoundDesc,
+	packetsDesc, connectionsDesc, rpcsDesc, rpcErrorsDesc, requestsDesc,
+	v4OperationsDesc *prometheus.Desc
+}
+
+func init() {
+	Factories["nfsd"] = NewNfsdCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// NFS server statistics.
+func NewNfsdCollector() (Collector, error) {
+	return &nfsdCollector{
+		replyCacheDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "reply_cache_requests_total"),
+			"Number of requests by reply cache result (hit, miss or nocache).",
+			[]string{"result"}, nil,
+		),
+		staleFileHandlesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "file_handles_stale_total"),
+			"Number of stale file handles.",
+			nil, nil,
+		),
+		diskBytesReadDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "disk_read_bytes_total"),
+			"Number of bytes read from disk by the NFS server.",
+			nil, nil,
+		),
+		diskBytesWrittenDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "disk_written_bytes_total"),
+			"Number of bytes written to disk by the NFS server.",
+			nil, nil,
+		),
+		threadsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "server_threads"),
+			"Number of NFS server threads.",
+			nil, nil,
+		),
+		threadsFullDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "server_threads_full_total"),
+			"Number of times all NFS server threads were busy.",
+			nil, nil,
+		),
+		threadUsageDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "server_thread_usage_seconds_total"),
+			"Time spent with the given percentage of NFS server threads busy.",
+			[]string{"percent"}, nil,
+		),
+		readAheadSizeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "read_ahead_cache_size_blocks"),
+			"Size of the read-ahead cache.",
+			nil, nil,
+		),
+		readAheadHitsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "read_ahead_cache_hits_total"),
+			"Number of read-ahead cache hits by depth in percent of the cache.",
+			[]string{"percent"}, nil,
+		),
+		readAheadNotFoundDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "read_ahead_cache_not_found_total"),
+			"Number of read-ahead cache misses.",
+			nil, nil,
+		),
+		packetsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "packets_total"),
+			"Number of NFS packets received by the server by protocol.",
+			[]string{"protocol"}, nil,
+		),
+		connectionsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "connections_total"),
+			"Number of TCP connections accepted by the NFS server.",
+			nil, nil,
+		),
+		rpcsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "rpcs_total"),
+			"Number of RPC calls received by the NFS server.",
+			nil, nil,
+		),
+		rpcErrorsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "rpc_errors_total"),
+			"Number of bad RPC calls received by the NFS server by error.",
+			[]string{"error"}, nil,
+		),
+		requestsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "requests_total"),
+			"Number of NFS procedures served by protocol version and procedure.",
+			[]string{"version", "procedure"}, nil,
+		),
+		v4OperationsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nfsdSubsystem, "v4_operations_total"),
+			"Number of NFSv4 operations served by operation.",
+			[]string{"operation"}, nil,
+		),
+	}, nil
+}
+
+func (c *nfsdCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	stats, err := readNfsdStats(procFilePath("net/rpc/nfsd"))
+	if os.IsNotExist(err) {
+		log.Debugf("Not collecting NFS server stats, nfsd module not loaded")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't get NFS server stats: %s", err)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.replyCacheDesc, prometheus.CounterValue, stats.replyCache.hits, "hit")
+	ch <- prometheus.MustNewConstMetric(c.replyCacheDesc, prometheus.CounterValue, stats.replyCache.misses, "miss")
+	ch <- prometheus.MustNewConstMetric(c.replyCacheDesc, prometheus.CounterValue, stats.replyCache.noCache, "nocache")
+	ch <- prometheus.MustNewConstMetric(c.staleFileHandlesDesc, prometheus.CounterValue, stats.staleFileHandles)
+	ch <- prometheus.MustNewConstMetric(c.diskBytesReadDesc, prometheus.CounterValue, stats.io.read)
+	ch <- prometheus.MustNewConstMetric(c.diskBytesWrittenDesc, prometheus.CounterValue, stats.io.written)
+
+	ch <- prometheus.MustNewConstMetric(c.threadsDesc, prometheus.GaugeValue, stats.threads.count)
+	ch <- prometheus.MustNewConstMetric(c.threadsFullDesc, prometheus.CounterValue, stats.threads.fullCount)
+	for i, v := range stats.threads.usage {
+		ch <- prometheus.MustNewConstMetric(c.threadUsageDesc, prometheus.CounterValue, v, nfsdHistogramBuckets[i])
+	}
+
+	if stats.hasReadAhead {
+		ch <- prometheus.MustNewConstMetric(c.readAheadSizeDesc, prometheus.GaugeValue, stats.readAhead.size)
+		for i, v := range stats.readAhead.depths {
+			ch <- prometheus.MustNewConstMetric(c.readAheadHitsDesc, prometheus.CounterValue, v, nfsdHistogramBuckets[i])
+		}
+		ch <- prometheus.MustNewConstMetric(c.readAheadNotFoundDesc, prometheus.CounterValue, stats.readAhead.notFound)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.packetsDesc, prometheus.CounterValue, stats.net.udp, "udp")
+	ch <- prometheus.MustNewConstMetric(c.packetsDesc, prometheus.CounterValue, stats.net.tcp, "tcp")
+	ch <- prometheus.MustNewConstMetric(c.connectionsDesc, prometheus.CounterValue, stats.net.tcpConnections)
+	ch <- prometheus.MustNewConstMetric(c.rpcsDesc, prometheus.CounterValue, stats.rpc.calls)
+	ch <- prometheus.MustNewConstMetric(c.rpcErrorsDesc, prometheus.CounterValue, stats.rpc.badFormat, "format")
+	ch <- prometheus.MustNewConstMetric(c.rpcErrorsDesc, prometheus.CounterValue, stats.rpc.badAuth, "auth")
+	ch <- prometheus.MustNewConstMetric(c.rpcErrorsDesc, prometheus.CounterValue, stats.rpc.badClient, "client")
+
+	for version, counts := range stats.procedures {
+		names := nfsProcedures[version]
+		if version == "4" {
+			names = nfsdV4Procedures
+		}
+		for i, v := range counts {
+			procedure := strconv.Itoa(i)
+			if i < len(names) {
+				procedure = names[i]
+			}
+			ch <- prometheus.MustNewConstMetric(c.requestsDesc, prometheus.CounterValue, v, version, procedure)
+		}
+	}
+
+	// Only export the operations the kernel provides.
+	for i, v := range stats.v4Operations {
+		operation := strconv.Itoa(i)
+		if i < len(nfsdV4Operations) {
+			operation = nfsdV4Operations[i]
+		}
+		if operation == "" {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.v4OperationsDesc, prometheus.CounterValue, v, operation)
+	}
+	return nil
+}
+
+func readNfsdStats(name string) (nfsdStats, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nfsdStats{}, err
+	}
+	defer file.Close()
+
+	return parseNfsdStats(file)
+}
+
+func parseNfsdStats(r io.Reader) (nfsdStats, error) {
+	var (
+		stats   = nfsdStats{procedures: map[string][]float64{}}
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 {
+			continue
+		}
+		values, err := parseNfsValues(parts[1:])
+		if err != nil {
+			return stats, err
+		}
+
+		key := parts[0]
+		if min, ok := map[string]int{"rc": 3, "fh": 1, "io": 2, "th": 2, "ra": 12, "net": 4, "rpc": 5}[key]; ok && len(values) < min {
+			return stats, fmt.Errorf("invalid %s line in nfsd stats: %s", key, scanner.Text())
+		}
+		switch {
+		case key == "rc":
+			stats.replyCache.hits, stats.replyCache.misses, stats.replyCache.noCache = values[0], values[1], values[2]
+		case key == "fh":
+			stats.staleFileHandles = values[0]
+		case key == "io":
+			stats.io.read, stats.io.written = values[0], values[1]
+		case key == "th":
+			stats.threads.count, stats.threads.fullCount = values[0], values[1]
+			usage := values[2:]
+			if len(usage) > len(nfsdHistogramBuckets) {
+				usage = usage[:len(nfsdHistogramBuckets)]
+			}
+			stats.threads.usage = usage
+		case key == "ra":
+			stats.hasReadAhead = true
+			stats.readAhead.size, stats.readAhead.depths, stats.readAhead.notFound = values[0], values[1:11], values[11]
+		case key == "net":
+			stats.net.udp, stats.net.tcp, stats.net.tcpConnections = values[1], values[2], values[3]
+		case key == "rpc":
+			stats.rpc.calls, stats.rpc.badFormat, stats.rpc.badAuth, stats.rpc.badClient = values[0], values[2], values[3], values[4]
+		case key == "proc4ops":
+			if int(values[0]) != len(values)-1 {
+				return stats, fmt.Errorf("invalid %s line in nfsd stats: %s", key, scanner.Text())
+			}
+			stats.v4Operations = values[1:]
+		case strings.HasPrefix(key, "proc"):
+			// The first value is the number of procedures that follow.
+			if int(values[0]) != len(values)-1 {
+				return stats, fmt.Errorf("invalid %s line in nfsd stats: %s", key, scanner.Text())
+			}
+			stats.procedures[strings.TrimPrefix(key, "proc")] = values[1:]
+		}
+	}
+	return stats, scanner.Err()
+}
@@ -0,0 +1,93 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNfsdStats(t *testing.T) {
+	stats, err := readNfsdStats("fixtures/proc/net/rpc/nfsd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 18622.0, stats.replyCache.noCache; want != got {
+		t.Errorf("want %f uncached replies, got %f", want, got)
+	}
+
+	if want, got := 2.0, stats.staleFileHandles; want != got {
+		t.Errorf("want %f stale file handles, got %f", want, got)
+	}
+
+	if want, got := 72864.0, stats.io.written; want != got {
+		t.Errorf("want %f bytes written, got %f", want, got)
+	}
+
+	if want, got := 8.0, stats.threads.count; want != got {
+		t.Errorf("want %f threads, got %f", want, got)
+	}
+
+	if want, got := 10, len(stats.threads.usage); want != got {
+		t.Errorf("want %d thread usage buckets, got %d", want, got)
+	}
+
+	if !stats.hasReadAhead || stats.readAhead.size != 32 {
+		t.Errorf("want read-ahead cache of size 32, got %v", stats.readAhead)
+	}
+
+	if want, got := 2.0, stats.rpc.badAuth; want != got {
+		t.Errorf("want %f bad auth calls, got %f", want, got)
+	}
+
+	if want, got := 10853.0, stats.procedures["4"][1]; want != got {
+		t.Errorf("want %f compound requests, got %f", want, got)
+	}
+
+	if want, got := 59, len(stats.v4Operations); want != got {
+		t.Fatalf("want %d v4 operations, got %d", want, got)
+	}
+
+	if want, got := 52388.0, stats.v4Operations[22]; want != got {
+		t.Errorf("want %f putfh operations, got %f", want, got)
+	}
+}
+
+func TestNfsdStatsOldKernel(t *testing.T) {
+	stats, err := parseNfsdStats(strings.NewReader(`rc 0 6 18622
+fh 0 0 0 0 0
+io 0 0
+th 8 0 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000
+net 18628 0 18628 6
+rpc 18628 0 0 0 0
+proc4 2 2 10853
+proc4ops 40 0 0 0 22734 1098 2 3 0 1236 40934 9609 2 4 0 4 5900 0 0 1272 0 0 0 52388 0 2 8179 150 12 6 2 0 1236 2472 0 97 2 2 0 5896 0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.hasReadAhead {
+		t.Error("want no read-ahead cache")
+	}
+
+	if want, got := 40, len(stats.v4Operations); want != got {
+		t.Errorf("want %d v4 operations, got %d", want, got)
+	}
+
+	if _, err := parseNfsdStats(strings.NewReader("proc4ops 72 0 0 0\n")); err == nil {
+		t.Error("want error for truncated operations line")
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noos
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	osSubsystem = "os"
+)
+
+var (
+	// Locations of os-release(5), in order of precedence.
+	osReleaseFiles = []string{"etc/os-release", "usr/lib/os-release"}
+	// Leading numeric part of VERSION_ID, e.g. 3.18 of 3.18.4.
+	osVersionRE = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?`)
+
+	// Bits of /proc/sys/kernel/tainted, see
+	// Documentation/admin-guide/tainted-kernels.rst in the kernel sources.
+	kernelTaintFlags = []string{
+		"proprietary_module",
+		"forced_module",
+		"cpu_out_of_spec",
+		"forced_rmmod",
+		"machine_check",
+		"bad_page",
+		"user",
+		"die",
+		"overridden_acpi_table",
+		"warn",
+		"staging_driver",
+		"firmware_workaround",
+		"out_of_tree_module",
+		"unsigned_module",
+		"soft_lockup",
+		"livepatch",
+		"auxiliary",
+		"randstruct",
+		"test",
+	}
+)
+
+type osCollector struct {
+	infoDesc, versionDesc, rebootRequiredDesc, rebootPackageDesc,
+	taintedDesc, taintDesc, cmdlineDesc *prometheus.Desc
+}
+
+func init() {
+	Factories["os"] = NewOSCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// operating system release, reboot and kernel taint information.
+func NewOSCollector() (Collector, error) {
+	return &osCollector{
+		infoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, osSubsystem, "info"),
+			"Labeled operating system information as provided by os-release.",
+			[]string{"id", "version_id", "pretty_name", "version_codename"}, nil,
+		),
+		versionDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, osSubsystem, "version"),
+			"Numeric version of the operating system as provided by os-release.",
+			[]string{"id", "version_id"}, nil,
+		),
+		rebootRequiredDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, osSubsystem, "reboot_required"),
+			"Whether the system requires a reboot to finish installing updates.",
+			nil, nil,
+		),
+		rebootPackageDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, osSubsystem, "reboot_required_package_info"),
+			"Package whose update requires a reboot.",
+			[]string{"package"}, nil,
+		),
+		taintedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, osSubsystem, "kernel_tainted"),
+			"Taint flags of the kernel as a bitmask.",
+			nil, nil,
+		),
+		taintDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, osSubsystem, "kernel_taint"),
+			"Whether the kernel is tainted for the reason.",
+			[]string{"bit", "reason"}, nil,
+		),
+		cmdlineDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, osSubsystem, "kernel_cmdline_info"),
+			"Command line the running kernel was booted with.",
+			[]string{"cmdline"}, nil,
+		),
+	}, nil
+}
+
+func (c *osCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	release, err := getOSRelease()
+	if err != nil {
+		return fmt.Errorf("couldn't get os-release: %s", err)
+	}
+	if release != nil {
+		id, versionID := release["ID"], release["VERSION_ID"]
+		ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1,
+			id, versionID, release["PRETTY_NAME"], release["VERSION_CODENAME"])
+		if v := osVersionRE.FindString(versionID); v != "" {
+			version, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("invalid VERSION_ID %s: %s", versionID, err)
+			}
+			ch <- prometheus.MustNewConstMetric(c.versionDesc, prometheus.GaugeValue, version, id, versionID)
+		}
+	}
+
+	required, packages, err := getRebootRequired()
+	if err != nil {
+		return fmt.Errorf("couldn't get reboot required: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.rebootRequiredDesc, prometheus.GaugeValue, required)
+	for _, pkg := range packages {
+		ch <- prometheus.MustNewConstMetric(c.rebootPackageDesc, prometheus.GaugeValue, 1, pkg)
+	}
+
+	tainted, err := readUintFromFile(procFilePath("sys/kernel/tainted"))
+	switch {
+	case os.IsNotExist(err):
+		log.Debugf("Not collecting kernel taint flags, file does not exist")
+	case err != nil:
+		return fmt.Errorf("couldn't get kernel taint flags: %s", err)
+	default:
+		ch <- prometheus.MustNewConstMetric(c.taintedDesc, prometheus.GaugeValue, float64(tainted))
+		for bit, reason := range kernelTaints(tainted) {
+			var v float64
+			if tainted&(1<<uint(bit)) != 0 {
+				v = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.taintDesc, prometheus.GaugeValue, v, strconv.Itoa(bit), reason)
+		}
+	}
+
+	cmdline, err := ioutil.ReadFile(procFilePath("cmdline"))
+	if err != nil {
+		return fmt.Errorf("couldn't get kernel command line: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.cmdlineDesc, prometheus.GaugeValue, 1, strings.TrimSpace(string(cmdline)))
+	return nil
+}
+
+// Returns the reasons of all known bits of the taint mask, and of unknown
+// bits that are set.
+func kernelTaints(tainted uint64) map[int]string {
+	taints := map[int]string{}
+	for bit := 0; bit < 64; bit++ {
+		switch {
+		case bit < len(kernelTaintFlags):
+			taints[bit] = kernelTaintFlags[bit]
+		case tainted&(1<<uint(bit)) != 0:
+			taints[bit] = "unknown"
+		}
+	}
+	return taints
+}
+
+// Returns the variables of the first os-release file found, or nil if
+// there is none.
+func getOSRelease() (map[string]string, error) {
+	for _, name := range osReleaseFiles {
+		file, err := os.Open(rootfsFilePath(name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return parseOSRelease(file)
+	}
+	log.Debugf("Not collecting os-release, no file found")
+	return nil, nil
+}
+
+// Parses the environment-like assignments of os-release(5), which are
+// optionally quoted with shell quoting rules.
+func parseOSRelease(r io.Reader) (map[string]string, error) {
+	var (
+		release = map[string]string{}
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid line in os-release: %s", line)
+		}
+		value := kv[1]
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\$`, `$`, "\\`", "`").Replace(value[1 : len(value)-1])
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		}
+		release[kv[0]] = value
+	}
+	return release, scanner.Err()
+}
+
+// Returns 1 if the system requires a reboot as signaled by Debian's
+// update-notifier, along with the packages that caused it. It writes to
+// /var/run, which is an absolute symlink to /run on current systems and
+// would resolve outside of the rootfs.
+func getRebootRequired() (float64, []string, error) {
+	for _, dir := range []string{"run", "var/run"} {
+		dir = rootfsFilePath(dir)
+		if info, err := os.Lstat(dir); err != nil || info.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+
+		_, err := os.Stat(path.Join(dir, "reboot-required"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+
+		packages, err := getRebootRequiredPackages(path.Join(dir, "reboot-required.pkgs"))
+		if err != nil {
+			return 0, nil, err
+		}
+		return 1, packages, nil
+	}
+	return 0, nil, nil
+}
+
+func getRebootRequiredPackages(name string) ([]string, error) {
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Packages are listed once for every update requiring the reboot.
+	var (
+		packages []string
+		seen     = map[string]bool{}
+	)
+	for _, pkg := range strings.Fields(string(data)) {
+		if !seen[pkg] {
+			seen[pkg] = true
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
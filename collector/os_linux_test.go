@@ -0,0 +1,125 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestOSRelease(t *testing.T) {
+	defer flag.Set("collector.rootfs", *rootfsPath)
+	if err := flag.Set("collector.rootfs", "fixtures/rootfs"); err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := getOSRelease()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "Ubuntu 22.04.3 LTS", release["PRETTY_NAME"]; want != got {
+		t.Errorf("want pretty name %q, got %q", want, got)
+	}
+
+	if want, got := "jammy", release["VERSION_CODENAME"]; want != got {
+		t.Errorf("want codename %q, got %q", want, got)
+	}
+
+	release, err = parseOSRelease(strings.NewReader("# comment\nNAME='Foo Linux'\nVARIANT=\"\\\"Edge\\\" \\$HOME\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "Foo Linux", release["NAME"]; want != got {
+		t.Errorf("want single quoted value %q, got %q", want, got)
+	}
+
+	if want, got := `"Edge" $HOME`, release["VARIANT"]; want != got {
+		t.Errorf("want escaped value %q, got %q", want, got)
+	}
+
+	required, packages, err := getRebootRequired()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1.0, required; want != got {
+		t.Errorf("want reboot required %f, got %f", want, got)
+	}
+
+	if want, got := 2, len(packages); want != got {
+		t.Errorf("want %d packages requiring a reboot, got %v", want, packages)
+	}
+}
+
+func TestRebootRequiredVarRun(t *testing.T) {
+	root, err := ioutil.TempDir("", "node_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	defer flag.Set("collector.rootfs", *rootfsPath)
+	if err := flag.Set("collector.rootfs", root); err != nil {
+		t.Fatal(err)
+	}
+
+	required, _, err := getRebootRequired()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 0.0, required; want != got {
+		t.Errorf("want reboot required %f, got %f", want, got)
+	}
+
+	// Older systems have a /var/run directory.
+	if err := os.MkdirAll(path.Join(root, "var/run"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(root, "var/run/reboot-required"), []byte("*** System restart required ***\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	required, packages, err := getRebootRequired()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1.0, required; want != got {
+		t.Errorf("want reboot required %f, got %f", want, got)
+	}
+	if want, got := 0, len(packages); want != got {
+		t.Errorf("want %d packages requiring a reboot, got %v", want, packages)
+	}
+}
+
+func TestKernelTaints(t *testing.T) {
+	taints := kernelTaints(1<<12 | 1<<40)
+
+	if want, got := "out_of_tree_module", taints[12]; want != got {
+		t.Errorf("want reason %s, got %s", want, got)
+	}
+
+	if want, got := "unknown", taints[40]; want != got {
+		t.Errorf("want reason %s for unknown bit, got %s", want, got)
+	}
+
+	if want, got := len(kernelTaintFlags)+1, len(taints); want != got {
+		t.Errorf("want %d taints, got %d", want, got)
+	}
+}
@@ -24,6 +24,9 @@ var (
 	// The path of the proc filesystem.
 	procPath = flag.String("collector.procfs", procfs.DefaultMountPoint, "procfs mountpoint.")
 	sysPath  = flag.String("collector.sysfs", "/sys", "sysfs mountpoint.")
+	// The path the host's root filesystem is mounted at, e.g. when running
+	// in a container.
+	rootfsPath = flag.String("collector.rootfs", "/", "rootfs mountpoint.")
 )
 
 func procFilePath(name string) string {
@@ -33,3 +36,7 @@ func procFilePath(name string) string {
 func sysFilePath(name string) string {
 	return path.Join(*sysPath, name)
 }
+
+func rootfsFilePath(name string) string {
+	return path.Join(*rootfsPath, name)
+}
@@ -75,3 +75,25 @@ func TestCustomSysPath(t *testing.T) {
 		t.Errorf("Expected: %s, Got: %s", want, got)
 	}
 }
+
+func TestDefaultRootfsPath(t *testing.T) {
+	defer flag.Set("collector.rootfs", *rootfsPath)
+	if err := flag.Set("collector.rootfs", "/"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := rootfsFilePath("etc/os-release"), "/etc/os-release"; got != want {
+		t.Errorf("Expected: %s, Got: %s", want, got)
+	}
+}
+
+func TestCustomRootfsPath(t *testing.T) {
+	defer flag.Set("collector.rootfs", *rootfsPath)
+	if err := flag.Set("collector.rootfs", "./../some/./place/"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := rootfsFilePath("etc/os-release"), "../some/place/etc/os-release"; got != want {
+		t.Errorf("Expected: %s, Got: %s", want, got)
+	}
+}
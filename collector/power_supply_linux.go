@@ -0,0 +1,177 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nopower_supply
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	powerSupplySubsystem = "power_supply"
+)
+
+var (
+	// Numeric attributes of a power supply and the factor converting them
+	// to base units. The kernel reports capacity in percent, energy in µWh,
+	// charge in µAh, voltage, current and power in µV, µA and µW and the
+	// temperature in tenths of °C. Which attributes exist depends on the
+	// type of the power supply and its driver.
+	powerSupplyFields = []struct {
+		file, name, help string
+		factor           float64
+	}{
+		{"online", "online", "Whether the power supply is connected to its source.", 1},
+		{"present", "present", "Whether the battery is present.", 1},
+		{"capacity", "capacity_ratio", "Remaining capacity of the battery relative to its full capacity.", 0.01},
+		{"energy_now", "energy_joules", "Energy stored in the battery.", 0.0036},
+		{"energy_full", "energy_full_joules", "Energy stored in the battery when it was last fully charged.", 0.0036},
+		{"energy_full_design", "energy_full_design_joules", "Energy the battery is designed to store when full.", 0.0036},
+		{"charge_now", "charge_coulombs", "Charge stored in the battery.", 0.0036},
+		{"charge_full", "charge_full_coulombs", "Charge stored in the battery when it was last fully charged.", 0.0036},
+		{"charge_full_design", "charge_full_design_coulombs", "Charge the battery is designed to store when full.", 0.0036},
+		{"voltage_now", "voltage_volts", "Present voltage of the power supply.", 1e-6},
+		{"voltage_min_design", "voltage_min_design_volts", "Minimal design voltage of the battery.", 1e-6},
+		{"current_now", "current_amps", "Present current drawn from or supplied to the battery.", 1e-6},
+		{"power_now", "power_watts", "Present power drawn from or supplied to the battery.", 1e-6},
+		{"temp", "temp_celsius", "Temperature of the power supply.", 0.1},
+		{"cycle_count", "cycle_count", "Number of charge and discharge cycles of the battery.", 1},
+	}
+	powerSupplyInfoFiles = []string{"type", "status", "technology", "manufacturer", "model_name"}
+)
+
+type powerSupply struct {
+	name   string
+	values map[string]float64 // file -> value
+	info   map[string]string  // file -> value
+}
+
+type powerSupplyCollector struct {
+	fieldDescs []*prometheus.Desc
+	infoDesc   *prometheus.Desc
+}
+
+func init() {
+	Factories["power_supply"] = NewPowerSupplyCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// the state of batteries, AC adapters and UPSes.
+func NewPowerSupplyCollector() (Collector, error) {
+	fieldDescs := make([]*prometheus.Desc, 0, len(powerSupplyFields))
+	for _, f := range powerSupplyFields {
+		fieldDescs = append(fieldDescs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, f.name),
+			f.help, []string{"power_supply"}, nil,
+		))
+	}
+	return &powerSupplyCollector{
+		fieldDescs: fieldDescs,
+		infoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "info"),
+			"Type, status and model of the power supply.",
+			append([]string{"power_supply"}, powerSupplyInfoFiles...), nil,
+		),
+	}, nil
+}
+
+func (c *powerSupplyCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	supplies, err := getPowerSupplies()
+	if err != nil {
+		return fmt.Errorf("couldn't get power supplies: %s", err)
+	}
+
+	for _, ps := range supplies {
+		for i, f := range powerSupplyFields {
+			if v, ok := ps.values[f.file]; ok {
+				ch <- prometheus.MustNewConstMetric(c.fieldDescs[i], prometheus.GaugeValue, v, ps.name)
+			}
+		}
+
+		labels := []string{ps.name}
+		for _, file := range powerSupplyInfoFiles {
+			labels = append(labels, ps.info[file])
+		}
+		ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, labels...)
+	}
+	return nil
+}
+
+func getPowerSupplies() ([]powerSupply, error) {
+	dirs, err := filepath.Glob(sysFilePath("class/power_supply/*"))
+	if err != nil {
+		return nil, err
+	}
+
+	supplies := make([]powerSupply, 0, len(dirs))
+	for _, dir := range dirs {
+		ps, err := readPowerSupply(dir)
+		if err != nil {
+			return nil, err
+		}
+		supplies = append(supplies, ps)
+	}
+	return supplies, nil
+}
+
+func readPowerSupply(dir string) (powerSupply, error) {
+	ps := powerSupply{
+		name:   path.Base(dir),
+		values: map[string]float64{},
+		info:   map[string]string{},
+	}
+
+	for _, f := range powerSupplyFields {
+		value, ok := readPowerSupplyFile(path.Join(dir, f.file))
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return ps, fmt.Errorf("invalid %s of %s: %s", f.file, ps.name, err)
+		}
+		ps.values[f.file] = v * f.factor
+	}
+
+	for _, file := range powerSupplyInfoFiles {
+		if value, ok := readPowerSupplyFile(path.Join(dir, file)); ok {
+			ps.info[file] = value
+		}
+	}
+	return ps, nil
+}
+
+// Reads an attribute of a power supply, returning false if it doesn't
+// exist. Drivers return errors like ENODEV for attributes of batteries
+// that have been removed, which are skipped as well.
+func readPowerSupplyFile(name string) (string, bool) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debugf("Ignoring power supply attribute %s: %s", name, err)
+		}
+		return "", false
+	}
+	return strings.TrimSpace(string(data)), true
+}
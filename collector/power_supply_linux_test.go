@@ -0,0 +1,67 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestPowerSupply(t *testing.T) {
+	ps, err := readPowerSupply("fixtures/sys/class/power_supply/AC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1, len(ps.values); want != got {
+		t.Errorf("want %d values for AC adapter, got %v", want, ps.values)
+	}
+
+	if want, got := "Mains", ps.info["type"]; want != got {
+		t.Errorf("want type %s, got %s", want, got)
+	}
+
+	ps, err = readPowerSupply("fixtures/sys/class/power_supply/BAT0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 136044.0, ps.values["energy_now"]; want != got {
+		t.Errorf("want energy %f, got %f", want, got)
+	}
+
+	if want, got := 0.81, ps.values["capacity"]; want != got {
+		t.Errorf("want capacity %f, got %f", want, got)
+	}
+
+	if want, got := "Discharging", ps.info["status"]; want != got {
+		t.Errorf("want status %s, got %s", want, got)
+	}
+
+	ps, err = readPowerSupply("fixtures/sys/class/power_supply/BAT1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 18360.0, ps.values["charge_full"]; want != got {
+		t.Errorf("want full charge %f, got %f", want, got)
+	}
+
+	if want, got := 29.5, ps.values["temp"]; want != got {
+		t.Errorf("want temperature %f, got %f", want, got)
+	}
+
+	if _, ok := ps.values["energy_now"]; ok {
+		t.Error("want no energy for battery reporting charge")
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nopressure
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	pressureSubsystem = "pressure"
+)
+
+var (
+	pressureResources = []string{"cpu", "memory", "io"}
+	// Averages of each line, by their key and the window they're
+	// exported with.
+	pressureAverages = []struct{ key, window string }{
+		{"avg10", "10s"},
+		{"avg60", "60s"},
+		{"avg300", "300s"},
+	}
+)
+
+// Pressure stall information of one line of a /proc/pressure file.
+type pressureStats struct {
+	averages map[string]float64 // key -> ratio
+	total    float64            // seconds
+}
+
+type pressureCollector struct {
+	stalledDesc, averageDesc *prometheus.Desc
+}
+
+func init() {
+	Factories["pressure"] = NewPressureCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// pressure stall information.
+func NewPressureCollector() (Collector, error) {
+	return &pressureCollector{
+		stalledDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pressureSubsystem, "stalled_seconds_total"),
+			"Total time some (type=some) or all (type=full) non-idle tasks were stalled on the resource.",
+			[]string{"resource", "type"}, nil,
+		),
+		averageDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pressureSubsystem, "stalled_ratio"),
+			"Ratio of time some (type=some) or all (type=full) non-idle tasks were stalled on the resource, averaged over the window.",
+			[]string{"resource", "type", "window"}, nil,
+		),
+	}, nil
+}
+
+func (c *pressureCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	for _, resource := range pressureResources {
+		stats, err := readPressureStats(procFilePath(path.Join("pressure", resource)))
+		if os.IsNotExist(err) {
+			log.Debugf("Not collecting %s pressure, file does not exist", resource)
+			continue
+		}
+		// Kernels with PSI support disabled at boot (psi=0) refuse reads.
+		if pe, ok := err.(*os.PathError); ok && pe.Err == syscall.EOPNOTSUPP {
+			log.Debugf("Not collecting %s pressure, PSI is disabled", resource)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't get %s pressure: %s", resource, err)
+		}
+		for typ, s := range stats {
+			ch <- prometheus.MustNewConstMetric(c.stalledDesc, prometheus.CounterValue, s.total, resource, typ)
+			for _, a := range pressureAverages {
+				ch <- prometheus.MustNewConstMetric(c.averageDesc, prometheus.GaugeValue, s.averages[a.key], resource, typ, a.window)
+			}
+		}
+	}
+	return nil
+}
+
+func readPressureStats(name string) (map[string]pressureStats, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parsePressureStats(file)
+}
+
+// Parses a /proc/pressure file, which consists of a some and, except for
+// cpu on kernels before 5.13, a full line like
+// "some avg10=0.00 avg60=0.00 avg300=0.00 total=0". Averages are in percent,
+// the total in microseconds.
+func parsePressureStats(r io.Reader) (map[string]pressureStats, error) {
+	var (
+		stats   = map[string]pressureStats{}
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		if parts[0] != "some" && parts[0] != "full" {
+			return nil, fmt.Errorf("invalid line in pressure stats: %s", scanner.Text())
+		}
+
+		s := pressureStats{averages: map[string]float64{}}
+		for _, p := range parts[1:] {
+			kv := strings.SplitN(p, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid field %s in pressure stats", p)
+			}
+			v, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s in pressure stats: %s", kv[1], err)
+			}
+			if kv[0] == "total" {
+				s.total = v / 1e6
+			} else {
+				s.averages[kv[0]] = v / 100
+			}
+		}
+		stats[parts[0]] = s
+	}
+	return stats, scanner.Err()
+}
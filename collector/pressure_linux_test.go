@@ -0,0 +1,51 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPressure(t *testing.T) {
+	stats, err := readPressureStats("fixtures/proc/pressure/cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1, len(stats); want != got {
+		t.Errorf("want %d lines for cpu, got %d", want, got)
+	}
+
+	if want, got := 134.893618, stats["some"].total; want != got {
+		t.Errorf("want %f seconds of cpu stalls, got %f", want, got)
+	}
+
+	stats, err = readPressureStats("fixtures/proc/pressure/io")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 0.085, stats["some"].averages["avg60"]; want != got {
+		t.Errorf("want io avg60 %f, got %f", want, got)
+	}
+
+	if want, got := 2061.718391, stats["full"].total; want != got {
+		t.Errorf("want %f seconds of full io stalls, got %f", want, got)
+	}
+
+	if _, err := parsePressureStats(strings.NewReader("some avg10\n")); err == nil {
+		t.Error("want error for invalid field")
+	}
+}
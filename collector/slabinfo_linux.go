@@ -0,0 +1,142 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noslabinfo
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	slabInfoSubsystem = "slabinfo"
+)
+
+var (
+	slabInfoInclude = flag.String(
+		"collector.slabinfo.include", "",
+		"Regexp of slab caches to include. Empty includes all.")
+
+	// Columns of a slabinfo 2.x line following the name.
+	slabInfoFields = []struct {
+		name, help string
+	}{
+		{"active_objects", "Number of objects of the slab cache that are in use."},
+		{"objects", "Number of allocated objects of the slab cache."},
+		{"object_size_bytes", "Size of the objects of the slab cache."},
+		{"objects_per_slab", "Number of objects in each slab of the slab cache."},
+		{"pages_per_slab", "Number of pages of each slab of the slab cache."},
+	}
+)
+
+type slabInfoCollector struct {
+	include *regexp.Regexp
+	descs   []*prometheus.Desc
+}
+
+func init() {
+	Factories["slabinfo"] = NewSlabInfoCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// the object counts and sizes of slab caches.
+func NewSlabInfoCollector() (Collector, error) {
+	c := &slabInfoCollector{}
+	if *slabInfoInclude != "" {
+		re, err := regexp.Compile(*slabInfoInclude)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slabinfo include pattern: %s", err)
+		}
+		c.include = re
+	}
+	for _, f := range slabInfoFields {
+		c.descs = append(c.descs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, slabInfoSubsystem, f.name),
+			f.help, []string{"slab"}, nil,
+		))
+	}
+	return c, nil
+}
+
+func (c *slabInfoCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	slabs, err := getSlabInfo()
+	if err != nil {
+		return fmt.Errorf("couldn't get slabinfo: %s", err)
+	}
+	for name, values := range slabs {
+		if c.include != nil && !c.include.MatchString(name) {
+			continue
+		}
+		for i, v := range values {
+			ch <- prometheus.MustNewConstMetric(c.descs[i], prometheus.GaugeValue, v, name)
+		}
+	}
+	return nil
+}
+
+func getSlabInfo() (map[string][]float64, error) {
+	file, err := os.Open(procFilePath("slabinfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseSlabInfo(file)
+}
+
+// Parses /proc/slabinfo, which is only readable by root. Returns the
+// values of slabInfoFields by slab cache.
+func parseSlabInfo(r io.Reader) (map[string][]float64, error) {
+	var (
+		slabs   = map[string][]float64{}
+		scanner = bufio.NewScanner(r)
+	)
+
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("empty slabinfo")
+	}
+	if header := scanner.Text(); !strings.HasPrefix(header, "slabinfo - version: 2.") {
+		return nil, fmt.Errorf("unsupported slabinfo version: %s", header)
+	}
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 || strings.HasPrefix(parts[0], "#") {
+			continue
+		}
+		if len(parts) < len(slabInfoFields)+1 {
+			return nil, fmt.Errorf("invalid line in slabinfo: %s", scanner.Text())
+		}
+
+		values := make([]float64, 0, len(slabInfoFields))
+		for _, p := range parts[1 : len(slabInfoFields)+1] {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s in slabinfo: %s", p, err)
+			}
+			values = append(values, v)
+		}
+		slabs[parts[0]] = values
+	}
+	return slabs, scanner.Err()
+}
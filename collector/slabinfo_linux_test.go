@@ -0,0 +1,48 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSlabInfo(t *testing.T) {
+	file, err := os.Open("fixtures/proc/slabinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	slabs, err := parseSlabInfo(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 9, len(slabs); want != got {
+		t.Errorf("want %d slab caches, got %d", want, got)
+	}
+
+	dentry := slabs["dentry"]
+	for i, want := range []float64{2127153, 2166990, 192, 21, 1} {
+		if got := dentry[i]; want != got {
+			t.Errorf("want dentry %s %f, got %f", slabInfoFields[i].name, want, got)
+		}
+	}
+
+	if _, err := parseSlabInfo(strings.NewReader("slabinfo - version: 1.1\n")); err == nil {
+		t.Error("want error for unsupported version")
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nosoftirqs
+
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type softirqsCollector struct {
+	desc *prometheus.Desc
+}
+
+func init() {
+	Factories["softirqs"] = NewSoftirqsCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// softirq statistics.
+func NewSoftirqsCollector() (Collector, error) {
+	return &softirqsCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "softirqs_total"),
+			"Number of softirqs handled by cpu and type.",
+			[]string{"cpu", "type"}, nil,
+		),
+	}, nil
+}
+
+func (c *softirqsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	softirqs, err := getSoftirqs()
+	if err != nil {
+		return fmt.Errorf("couldn't get softirqs: %s", err)
+	}
+	for typ, values := range softirqs {
+		for cpu, v := range values {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, v, cpu, typ)
+		}
+	}
+	return nil
+}
+
+func getSoftirqs() (map[string]map[string]float64, error) {
+	file, err := os.Open(procFilePath("softirqs"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseSoftirqs(file)
+}
+
+// Parses /proc/softirqs and returns the count per type and cpu. The header
+// names the online cpus, e.g. CPU0, which needn't be contiguous.
+func parseSoftirqs(r io.Reader) (map[string]map[string]float64, error) {
+	var (
+		softirqs = map[string]map[string]float64{}
+		scanner  = bufio.NewScanner(r)
+	)
+
+	if !scanner.Scan() {
+		return nil, errors.New("softirqs empty")
+	}
+	cpus := strings.Fields(scanner.Text())
+	for i, cpu := range cpus {
+		cpus[i] = strings.TrimPrefix(cpu, "CPU")
+	}
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) != len(cpus)+1 {
+			return nil, fmt.Errorf("invalid line in softirqs: %s", scanner.Text())
+		}
+		values := make(map[string]float64, len(cpus))
+		for i, p := range parts[1:] {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s in softirqs: %s", p, err)
+			}
+			values[cpus[i]] = v
+		}
+		softirqs[strings.TrimSuffix(parts[0], ":")] = values
+	}
+	return softirqs, scanner.Err()
+}
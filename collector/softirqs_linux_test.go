@@ -0,0 +1,54 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSoftirqs(t *testing.T) {
+	file, err := os.Open("fixtures/proc/softirqs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	softirqs, err := parseSoftirqs(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 10, len(softirqs); want != got {
+		t.Errorf("want %d softirq types, got %d", want, got)
+	}
+
+	if want, got := 9231480.0, softirqs["NET_RX"]["0"]; want != got {
+		t.Errorf("want %f NET_RX softirqs on cpu 0, got %f", want, got)
+	}
+
+	if want, got := 224.0, softirqs["HRTIMER"]["3"]; want != got {
+		t.Errorf("want %f HRTIMER softirqs on cpu 3, got %f", want, got)
+	}
+
+	// Offline cpus are missing from the header.
+	softirqs, err = parseSoftirqs(strings.NewReader("  CPU0  CPU2\n  HI:  1  2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2.0, softirqs["HI"]["2"]; want != got {
+		t.Errorf("want %f HI softirqs on cpu 2, got %f", want, got)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nosoftnet
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	softnetSubsystem = "softnet"
+	// Column of /proc/net/softnet_stat with the cpu, added in Linux 5.10.
+	softnetCPUColumn = 12
+)
+
+// Columns of /proc/net/softnet_stat to export, see softnet_seq_show in
+// net/core/net-procfs.c. Older kernels have fewer columns.
+var softnetFields = []struct {
+	column     int
+	name, help string
+}{
+	{0, "processed_total", "Number of packets processed by the cpu."},
+	{1, "dropped_total", "Number of packets dropped because the backlog of the cpu was full."},
+	{2, "times_squeezed_total", "Number of times net_rx_action ran out of budget or time with work remaining."},
+	{9, "received_rps_total", "Number of times the cpu was woken up to process packets steered to it by RPS."},
+	{10, "flow_limit_total", "Number of times the flow limit was reached on the cpu."},
+}
+
+type softnetCollector struct {
+	descs []*prometheus.Desc
+}
+
+func init() {
+	Factories["softnet"] = NewSoftnetCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// softnet statistics.
+func NewSoftnetCollector() (Collector, error) {
+	descs := make([]*prometheus.Desc, 0, len(softnetFields))
+	for _, f := range softnetFields {
+		descs = append(descs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, softnetSubsystem, f.name),
+			f.help, []string{"cpu"}, nil,
+		))
+	}
+	return &softnetCollector{descs: descs}, nil
+}
+
+func (c *softnetCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	stats, err := getSoftnetStats()
+	if err != nil {
+		return fmt.Errorf("couldn't get softnet stats: %s", err)
+	}
+	for cpu, values := range stats {
+		for i, f := range softnetFields {
+			if f.column >= len(values) {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.descs[i], prometheus.CounterValue, values[f.column], cpu)
+		}
+	}
+	return nil
+}
+
+func getSoftnetStats() (map[string][]float64, error) {
+	file, err := os.Open(procFilePath("net/softnet_stat"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseSoftnetStats(file)
+}
+
+// Parses /proc/net/softnet_stat, which has a line of hexadecimal values per
+// online cpu, and returns the values per cpu.
+func parseSoftnetStats(r io.Reader) (map[string][]float64, error) {
+	var (
+		stats   = map[string][]float64{}
+		scanner = bufio.NewScanner(r)
+	)
+
+	for line := 0; scanner.Scan(); line++ {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		values := make([]float64, 0, len(parts))
+		for _, p := range parts {
+			v, err := strconv.ParseUint(p, 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s in softnet_stat: %s", p, err)
+			}
+			values = append(values, float64(v))
+		}
+		// Without the cpu column the line number is the cpu, which is
+		// wrong if cpus before it are offline.
+		cpu := strconv.Itoa(line)
+		if len(values) > softnetCPUColumn {
+			cpu = strconv.Itoa(int(values[softnetCPUColumn]))
+		}
+		stats[cpu] = values
+	}
+	return stats, scanner.Err()
+}
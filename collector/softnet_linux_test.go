@@ -0,0 +1,58 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSoftnet(t *testing.T) {
+	file, err := os.Open("fixtures/proc/net/softnet_stat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stats, err := parseSoftnetStats(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 4, len(stats); want != got {
+		t.Fatalf("want %d cpus, got %d", want, got)
+	}
+
+	if want, got := 9214656.0, stats["0"][0]; want != got {
+		t.Errorf("want %f packets processed on cpu 0, got %f", want, got)
+	}
+
+	if want, got := 28.0, stats["2"][1]; want != got {
+		t.Errorf("want %f packets dropped on cpu 2, got %f", want, got)
+	}
+
+	if want, got := 65.0, stats["0"][2]; want != got {
+		t.Errorf("want %f time squeezes on cpu 0, got %f", want, got)
+	}
+
+	// Older kernels lack the cpu column.
+	stats, err = parseSoftnetStats(strings.NewReader("00000010 00000000 00000001 00000000 00000000 00000000 00000000 00000000 00000000 00000000\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 16.0, stats["0"][0]; want != got {
+		t.Errorf("want %f packets processed on cpu 0, got %f", want, got)
+	}
+}
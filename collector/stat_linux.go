@@ -17,19 +17,39 @@ package collector
 
 import (
 	"bufio"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
 )
 
 const (
-	userHz = 100
+	// USER_HZ on all but a few exotic architectures, used if the clock
+	// tick rate can't be determined.
+	defaultUserHz = 100
+	// Auxiliary vector entry of the clock tick rate, see <linux/auxvec.h>.
+	atClktck = 17
+)
+
+var (
+	statAggregateCPU = flag.Bool("collector.stat.aggregate-cpu", false, "Also export the aggregate of all cpus from /proc/stat as node_cpu_all.")
+
+	// Fields of the cpu lines, only some of these may be present depending
+	// on kernel version.
+	statCPUFields = []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal", "guest", "guest_nice"}
 )
 
 type statCollector struct {
+	userHz       float64
 	cpu          *prometheus.Desc
+	cpuAll       *prometheus.Desc
 	intr         *prometheus.Desc
 	ctxt         *prometheus.Desc
 	forks        *prometheus.Desc
@@ -46,11 +66,17 @@ func init() {
 // kernel/system statistics.
 func NewStatCollector() (Collector, error) {
 	return &statCollector{
+		userHz: getUserHz(),
 		cpu: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "cpu"),
 			"Seconds the cpus spent in each mode.",
 			[]string{"cpu", "mode"}, nil,
 		),
+		cpuAll: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "cpu_all"),
+			"Seconds all cpus together spent in each mode.",
+			[]string{"mode"}, nil,
+		),
 		intr: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "intr"),
 			"Total number of interrupts serviced.",
@@ -100,25 +126,22 @@ func (c *statCollector) Update(ch chan<- prometheus.Metric) (err error) {
 		}
 		switch {
 		case strings.HasPrefix(parts[0], "cpu"):
-			// Export only per-cpu stats, it can be aggregated up in prometheus.
-			if parts[0] == "cpu" {
+			// Export per-cpu stats, they can be aggregated up in prometheus.
+			// The aggregate line is only exported if asked for, as a
+			// separate metric so it isn't counted twice.
+			if parts[0] == "cpu" && !*statAggregateCPU {
 				break
 			}
-			// Only some of these may be present, depending on kernel version.
-			cpuFields := []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal", "guest"}
-			// OpenVZ guests lack the "guest" CPU field, which needs to be ignored.
-			expectedFieldNum := len(cpuFields) + 1
-			if expectedFieldNum > len(parts) {
-				expectedFieldNum = len(parts)
+			modes, err := parseStatCPU(parts[1:], c.userHz)
+			if err != nil {
+				return err
 			}
-			for i, v := range parts[1:expectedFieldNum] {
-				value, err := strconv.ParseFloat(v, 64)
-				if err != nil {
-					return err
+			for mode, value := range modes {
+				if parts[0] == "cpu" {
+					ch <- prometheus.MustNewConstMetric(c.cpuAll, prometheus.CounterValue, value, mode)
+				} else {
+					ch <- prometheus.MustNewConstMetric(c.cpu, prometheus.CounterValue, value, parts[0], mode)
 				}
-				// Convert from ticks to seconds
-				value /= userHz
-				ch <- prometheus.MustNewConstMetric(c.cpu, prometheus.CounterValue, value, parts[0], cpuFields[i])
 			}
 		case parts[0] == "intr":
 			// Only expose the overall number, use the 'interrupts' collector for more detail.
@@ -161,3 +184,83 @@ func (c *statCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	}
 	return err
 }
+
+// Parses the values of a cpu line of /proc/stat and returns the seconds
+// spent in each mode.
+func parseStatCPU(values []string, userHz float64) (map[string]float64, error) {
+	// Older kernels and OpenVZ guests lack the guest fields, newer kernels
+	// may add fields we don't know about.
+	if len(values) > len(statCPUFields) {
+		values = values[:len(statCPUFields)]
+	}
+	modes := make(map[string]float64, len(values))
+	for i, v := range values {
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, err
+		}
+		// Convert from ticks to seconds
+		modes[statCPUFields[i]] = value / userHz
+	}
+	return modes, nil
+}
+
+// Returns the clock tick rate /proc/stat uses, USER_HZ, falling back to
+// the usual one if it's unknown.
+func getUserHz() float64 {
+	hz, err := readUserHz()
+	if err != nil {
+		log.Debugf("Assuming USER_HZ %d: %s", defaultUserHz, err)
+		return defaultUserHz
+	}
+	return hz
+}
+
+// Reads USER_HZ from the ELF auxiliary vector passed to the exporter. That's
+// the one of the exporter process, so it's read from /proc regardless of
+// --collector.procfs.
+func readUserHz() (float64, error) {
+	file, err := os.Open("/proc/self/auxv")
+	if err != nil {
+		return 0, fmt.Errorf("couldn't read auxiliary vector: %s", err)
+	}
+	defer file.Close()
+
+	auxv, err := parseAuxv(file, nativeEndian())
+	if err != nil {
+		return 0, fmt.Errorf("couldn't parse auxiliary vector: %s", err)
+	}
+	hz, ok := auxv[atClktck]
+	if !ok || hz == 0 {
+		return 0, errors.New("no clock tick rate in auxiliary vector")
+	}
+	return float64(hz), nil
+}
+
+// Parses an auxiliary vector, which consists of pairs of native words with
+// the type and value of each entry.
+func parseAuxv(r io.Reader, order binary.ByteOrder) (map[uint64]uint64, error) {
+	var (
+		auxv     = map[uint64]uint64{}
+		wordSize = strconv.IntSize / 8
+		buf      = make([]byte, 2*wordSize)
+	)
+	for {
+		if _, err := io.ReadFull(r, buf); err == io.EOF {
+			return nil, errors.New("auxiliary vector not terminated")
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid auxiliary vector: %s", err)
+		}
+		var typ, value uint64
+		if wordSize == 8 {
+			typ, value = order.Uint64(buf[:8]), order.Uint64(buf[8:])
+		} else {
+			typ, value = uint64(order.Uint32(buf[:4])), uint64(order.Uint32(buf[4:]))
+		}
+		// AT_NULL terminates the vector.
+		if typ == 0 {
+			return auxv, nil
+		}
+		auxv[typ] = value
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStatCPU(t *testing.T) {
+	for _, c := range []struct {
+		line  string
+		modes int
+		want  map[string]float64
+	}{
+		// Aggregate line with all fields.
+		{"cpu  301854 612 111922 8979004 3552 2 3944 0 25 13", 10, map[string]float64{
+			"user": 3018.54, "nice": 6.12, "guest": 0.25, "guest_nice": 0.13,
+		}},
+		// OpenVZ guests lack the guest fields.
+		{"cpu0 44490 19 21045 1087069 220 1 3410 0", 8, map[string]float64{
+			"user": 444.90, "steal": 0,
+		}},
+		// Unknown fields of newer kernels are ignored.
+		{"cpu1 47869 23 16474 1110787 591 0 46 0 0 0 42", 10, map[string]float64{
+			"guest_nice": 0,
+		}},
+	} {
+		modes, err := parseStatCPU(strings.Fields(c.line)[1:], 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(modes) != c.modes {
+			t.Errorf("want %d modes for %q, got %v", c.modes, c.line, modes)
+		}
+		for mode, v := range c.want {
+			if got, ok := modes[mode]; !ok || got != v {
+				t.Errorf("want %s %f for %q, got %f", mode, v, c.line, got)
+			}
+		}
+	}
+
+	modes, err := parseStatCPU([]string{"250"}, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1.0, modes["user"]; want != got {
+		t.Errorf("want %f user seconds with USER_HZ 250, got %f", want, got)
+	}
+}
+
+func TestParseAuxv(t *testing.T) {
+	var (
+		buf     bytes.Buffer
+		entries = []uint64{
+			6, 4096, // AT_PAGESZ
+			17, 250, // AT_CLKTCK
+			0, 0, // AT_NULL
+		}
+	)
+	for _, e := range entries {
+		var err error
+		if strconv.IntSize == 64 {
+			err = binary.Write(&buf, binary.BigEndian, e)
+		} else {
+			err = binary.Write(&buf, binary.BigEndian, uint32(e))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	auxv, err := parseAuxv(bytes.NewReader(buf.Bytes()), binary.BigEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := uint64(250), auxv[atClktck]; want != got {
+		t.Errorf("want clock tick rate %d, got %d", want, got)
+	}
+	if want, got := uint64(4096), auxv[6]; want != got {
+		t.Errorf("want page size %d, got %d", want, got)
+	}
+
+	if _, err := parseAuxv(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), binary.BigEndian); err == nil {
+		t.Error("want error for truncated auxiliary vector")
+	}
+	if _, err := parseAuxv(bytes.NewReader(nil), binary.BigEndian); err == nil {
+		t.Error("want error for empty auxiliary vector")
+	}
+}
+
+func TestUserHz(t *testing.T) {
+	// The auxiliary vector of the test binary is the one of a real process,
+	// which doesn't depend on the procfs of the monitored host.
+	defer flag.Set("collector.procfs", *procPath)
+	if err := flag.Set("collector.procfs", "fixtures/proc"); err != nil {
+		t.Fatal(err)
+	}
+	hz, err := readUserHz()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hz <= 0 {
+		t.Errorf("want positive USER_HZ, got %f", hz)
+	}
+}
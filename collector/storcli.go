@@ -0,0 +1,342 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nomegacli
+
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var (
+	// Abbreviations used by storcli for virtual drive states.
+	storcliVirtualDriveStates = map[string]string{
+		"Optl": "optimal",
+		"Dgrd": "degraded",
+		"Pdgd": "partially_degraded",
+		"OfLn": "offline",
+		"Rec":  "recovery",
+		"Cac":  "cachecade",
+	}
+	// Abbreviations used by storcli for physical drive states.
+	storcliPhysicalDriveStates = map[string]string{
+		"Onln":   "online",
+		"Offln":  "offline",
+		"UGood":  "unconfigured_good",
+		"UBad":   "unconfigured_bad",
+		"Rbld":   "rebuild",
+		"GHS":    "global_hot_spare",
+		"DHS":    "dedicated_hot_spare",
+		"JBOD":   "jbod",
+		"Cpybck": "copyback",
+	}
+
+	storcliCacheRE       = regexp.MustCompile(`^(NR|R)(AWB|WB|WT)(C|D)$`)
+	storcliCachePolicies = map[string]string{
+		"R":   "read_ahead",
+		"NR":  "no_read_ahead",
+		"AWB": "always_write_back",
+		"WB":  "write_back",
+		"WT":  "write_through",
+		"C":   "cached",
+		"D":   "direct",
+	}
+	storcliTemperatureRE = regexp.MustCompile(`^(\d+) ?C$`)
+	storcliPercentRE     = regexp.MustCompile(`^(\d+) ?%$`)
+)
+
+type storcliOutput struct {
+	Controllers []struct {
+		CommandStatus struct {
+			Controller int    `json:"Controller"`
+			Status     string `json:"Status"`
+		} `json:"Command Status"`
+		ResponseData json.RawMessage `json:"Response Data"`
+	} `json:"Controllers"`
+}
+
+type storcliVirtualDrive struct {
+	DriveGroupVirtualDrive string `json:"DG/VD"`
+	Type                   string `json:"TYPE"`
+	State                  string `json:"State"`
+	Cache                  string `json:"Cache"`
+	Name                   string `json:"Name"`
+}
+
+type storcliPhysicalDrive struct {
+	EnclosureSlot string `json:"EID:Slt"`
+	State         string `json:"State"`
+}
+
+type storcliBattery struct {
+	Model string `json:"Model"`
+	State string `json:"State"`
+	Temp  string `json:"Temp"`
+}
+
+type storcliProperty struct {
+	Property string `json:"Property"`
+	Value    string `json:"Value"`
+}
+
+// Response data of `storcli /call show all J`.
+type storcliShowAll struct {
+	Status struct {
+		ControllerStatus          string  `json:"Controller Status"`
+		MemoryCorrectableErrors   float64 `json:"Memory Correctable Errors"`
+		MemoryUncorrectableErrors float64 `json:"Memory Uncorrectable Errors"`
+	} `json:"Status"`
+	HwCfg struct {
+		Alarm          string   `json:"Alarm"`
+		ROCTemperature *float64 `json:"ROC temperature(Degree Celsius)"`
+	} `json:"HwCfg"`
+	VirtualDrives  []storcliVirtualDrive  `json:"VD LIST"`
+	PhysicalDrives []storcliPhysicalDrive `json:"PD LIST"`
+	CacheVaults    []storcliBattery       `json:"Cachevault_Info"`
+	BBUs           []storcliBattery       `json:"BBU_Info"`
+}
+
+// Decodes storcli JSON output and returns the response data of each
+// controller the command succeeded on.
+func parseStorcli(r io.Reader) (map[int]json.RawMessage, error) {
+	var out storcliOutput
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return nil, fmt.Errorf("invalid storcli output: %s", err)
+	}
+
+	data := map[int]json.RawMessage{}
+	for _, c := range out.Controllers {
+		if c.CommandStatus.Status != "Success" {
+			log.Debugf("storcli command failed on controller %d: %s", c.CommandStatus.Controller, c.CommandStatus.Status)
+			continue
+		}
+		data[c.CommandStatus.Controller] = c.ResponseData
+	}
+	return data, nil
+}
+
+func parseStorcliShowAll(r io.Reader) (map[int]storcliShowAll, error) {
+	data, err := parseStorcli(r)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[int]storcliShowAll{}
+	for ctl, d := range data {
+		var s storcliShowAll
+		if err := json.Unmarshal(d, &s); err != nil {
+			return nil, fmt.Errorf("invalid storcli response for controller %d: %s", ctl, err)
+		}
+		stats[ctl] = s
+	}
+	return stats, nil
+}
+
+// Parses the output of `storcli /call/bbu show all J` or
+// `storcli /call/cv show all J` and returns the charge ratio per controller.
+func parseStorcliCharge(r io.Reader) (map[int]float64, error) {
+	data, err := parseStorcli(r)
+	if err != nil {
+		return nil, err
+	}
+
+	charge := map[int]float64{}
+	for ctl, d := range data {
+		var sections map[string]json.RawMessage
+		if err := json.Unmarshal(d, &sections); err != nil {
+			return nil, fmt.Errorf("invalid storcli response for controller %d: %s", ctl, err)
+		}
+		for _, section := range sections {
+			var props []storcliProperty
+			if err := json.Unmarshal(section, &props); err != nil {
+				// Not a property list.
+				continue
+			}
+			for _, p := range props {
+				if p.Property != "Relative State of Charge" && p.Property != "Capacitance" {
+					continue
+				}
+				matches := storcliPercentRE.FindStringSubmatch(p.Value)
+				if matches == nil {
+					return nil, fmt.Errorf("invalid %s on controller %d: %s", p.Property, ctl, p.Value)
+				}
+				v, err := strconv.ParseFloat(matches[1], 64)
+				if err != nil {
+					return nil, err
+				}
+				charge[ctl] = v / 100
+			}
+		}
+	}
+	return charge, nil
+}
+
+// Splits the storcli cache flags of a virtual drive, e.g. RWBD, into its
+// read, write and IO policy.
+func parseStorcliCache(cache string) (readPolicy, writePolicy, ioPolicy string, err error) {
+	matches := storcliCacheRE.FindStringSubmatch(cache)
+	if matches == nil {
+		return "", "", "", fmt.Errorf("invalid cache policy: %s", cache)
+	}
+	return storcliCachePolicies[matches[1]], storcliCachePolicies[matches[2]], storcliCachePolicies[matches[3]], nil
+}
+
+func parseStorcliTemperature(temp string) (float64, error) {
+	matches := storcliTemperatureRE.FindStringSubmatch(temp)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid temperature: %s", temp)
+	}
+	return strconv.ParseFloat(matches[1], 64)
+}
+
+func (c *megaCliCollector) runStorcli(args ...string) ([]byte, error) {
+	out, err := exec.Command(c.storcli, args...).Output()
+	// storcli exits non-zero if the command failed on any of the
+	// controllers, the status of each is part of the output.
+	if _, ok := err.(*exec.ExitError); ok && len(out) > 0 {
+		return out, nil
+	}
+	return out, err
+}
+
+func (c *megaCliCollector) updateStorcli() error {
+	// Drives may be removed and cache policies, which are labels, change,
+	// e.g. from write back to write through after a BBU failure.
+	for _, vec := range []*prometheus.GaugeVec{
+		c.controllerStatus, c.controllerAlarm, c.controllerTemperature,
+		c.virtualDriveState, c.virtualDriveCache, c.physicalDriveState,
+		c.bbuOptimal, c.bbuTemperature, c.bbuCharge,
+	} {
+		vec.Reset()
+	}
+	c.controllerMemoryErrors.Reset()
+
+	out, err := c.runStorcli("/call", "show", "all", "J")
+	if err != nil {
+		return err
+	}
+	stats, err := parseStorcliShowAll(bytes.NewReader(out))
+	if err != nil {
+		return err
+	}
+
+	for ctl, s := range stats {
+		ctlStr := strconv.Itoa(ctl)
+
+		var optimal float64
+		if s.Status.ControllerStatus == "Optimal" {
+			optimal = 1
+		}
+		c.controllerStatus.WithLabelValues(ctlStr).Set(optimal)
+
+		var alarm float64
+		if s.HwCfg.Alarm == "On" {
+			alarm = 1
+		}
+		c.controllerAlarm.WithLabelValues(ctlStr).Set(alarm)
+
+		if s.HwCfg.ROCTemperature != nil {
+			c.controllerTemperature.WithLabelValues(ctlStr).Set(*s.HwCfg.ROCTemperature)
+		}
+		c.controllerMemoryErrors.WithLabelValues(ctlStr, "correctable").Set(s.Status.MemoryCorrectableErrors)
+		c.controllerMemoryErrors.WithLabelValues(ctlStr, "uncorrectable").Set(s.Status.MemoryUncorrectableErrors)
+
+		for _, vd := range s.VirtualDrives {
+			parts := strings.SplitN(vd.DriveGroupVirtualDrive, "/", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid virtual drive on controller %d: %s", ctl, vd.DriveGroupVirtualDrive)
+			}
+			if _, ok := storcliVirtualDriveStates[vd.State]; !ok {
+				log.Debugf("Unknown state %s of virtual drive %s on controller %d", vd.State, vd.DriveGroupVirtualDrive, ctl)
+			}
+			for abbr, state := range storcliVirtualDriveStates {
+				var v float64
+				if abbr == vd.State {
+					v = 1
+				}
+				c.virtualDriveState.WithLabelValues(ctlStr, parts[0], parts[1], state).Set(v)
+			}
+
+			readPolicy, writePolicy, ioPolicy, err := parseStorcliCache(vd.Cache)
+			if err != nil {
+				log.Debugf("Ignoring cache policy of virtual drive %s on controller %d: %s", vd.DriveGroupVirtualDrive, ctl, err)
+				continue
+			}
+			c.virtualDriveCache.WithLabelValues(ctlStr, parts[0], parts[1], readPolicy, writePolicy, ioPolicy).Set(1)
+		}
+
+		for _, pd := range s.PhysicalDrives {
+			parts := strings.SplitN(pd.EnclosureSlot, ":", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid physical drive on controller %d: %s", ctl, pd.EnclosureSlot)
+			}
+			// Drives attached directly to the controller have no enclosure.
+			enc, slot := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			if _, ok := storcliPhysicalDriveStates[pd.State]; !ok {
+				log.Debugf("Unknown state %s of physical drive %s on controller %d", pd.State, pd.EnclosureSlot, ctl)
+			}
+			for abbr, state := range storcliPhysicalDriveStates {
+				var v float64
+				if abbr == pd.State {
+					v = 1
+				}
+				c.physicalDriveState.WithLabelValues(ctlStr, enc, slot, state).Set(v)
+			}
+		}
+
+		for typ, batteries := range map[string][]storcliBattery{"bbu": s.BBUs, "cachevault": s.CacheVaults} {
+			for _, b := range batteries {
+				var optimal float64
+				if b.State == "Optimal" {
+					optimal = 1
+				}
+				c.bbuOptimal.WithLabelValues(ctlStr, typ).Set(optimal)
+
+				// The temperature is N/A e.g. if the BBU failed.
+				t, err := parseStorcliTemperature(b.Temp)
+				if err != nil {
+					log.Debugf("Ignoring %s temperature on controller %d: %s", typ, ctl, err)
+					continue
+				}
+				c.bbuTemperature.WithLabelValues(ctlStr, typ).Set(t)
+			}
+		}
+	}
+
+	for typ, object := range map[string]string{"bbu": "/call/bbu", "cachevault": "/call/cv"} {
+		out, err := c.runStorcli(object, "show", "all", "J")
+		if err != nil {
+			return err
+		}
+		charge, err := parseStorcliCharge(bytes.NewReader(out))
+		if err != nil {
+			return err
+		}
+		for ctl, v := range charge {
+			c.bbuCharge.WithLabelValues(strconv.Itoa(ctl), typ).Set(v)
+		}
+	}
+
+	return nil
+}
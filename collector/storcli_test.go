@@ -0,0 +1,231 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nomegacli
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	testStorcliShowAll = "fixtures/storcli_show_all.json"
+	testStorcliCV      = "fixtures/storcli_cv_show_all.json"
+	testStorcliBBU     = "fixtures/storcli_bbu_show_all.json"
+)
+
+func TestStorcliShowAll(t *testing.T) {
+	data, err := os.Open(testStorcliShowAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer data.Close()
+
+	stats, err := parseStorcliShowAll(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 2, len(stats); want != got {
+		t.Fatalf("want %d controllers, got %d", want, got)
+	}
+
+	if want, got := "Optimal", stats[0].Status.ControllerStatus; want != got {
+		t.Errorf("want controller status %s, got %s", want, got)
+	}
+
+	if stats[0].HwCfg.ROCTemperature == nil || *stats[0].HwCfg.ROCTemperature != 63 {
+		t.Errorf("unexpected controller temperature: %v", stats[0].HwCfg.ROCTemperature)
+	}
+
+	if stats[1].HwCfg.ROCTemperature != nil {
+		t.Errorf("want no controller temperature, got %f", *stats[1].HwCfg.ROCTemperature)
+	}
+
+	if want, got := 1.0, stats[1].Status.MemoryUncorrectableErrors; want != got {
+		t.Errorf("want %f uncorrectable memory errors, got %f", want, got)
+	}
+
+	if want, got := "On", stats[1].HwCfg.Alarm; want != got {
+		t.Errorf("want alarm %s, got %s", want, got)
+	}
+
+	if want, got := "Dgrd", stats[0].VirtualDrives[1].State; want != got {
+		t.Errorf("want virtual drive state %s, got %s", want, got)
+	}
+
+	if want, got := "Rbld", stats[0].PhysicalDrives[3].State; want != got {
+		t.Errorf("want physical drive state %s, got %s", want, got)
+	}
+
+	if want, got := " :1", stats[1].PhysicalDrives[1].EnclosureSlot; want != got {
+		t.Errorf("want physical drive %q, got %q", want, got)
+	}
+
+	if len(stats[0].CacheVaults) != 1 || len(stats[0].BBUs) != 0 {
+		t.Errorf("want one CacheVault and no BBU on controller 0, got %v and %v", stats[0].CacheVaults, stats[0].BBUs)
+	}
+
+	if want, got := "Learning (Charging)", stats[1].BBUs[0].State; want != got {
+		t.Errorf("want BBU state %s, got %s", want, got)
+	}
+}
+
+func TestStorcliCharge(t *testing.T) {
+	for file, want := range map[string]map[int]float64{
+		testStorcliCV:  {0: 0.97},
+		testStorcliBBU: {1: 0.64},
+	} {
+		data, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer data.Close()
+
+		charge, err := parseStorcliCharge(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(charge) != len(want) {
+			t.Errorf("want charge %v from %s, got %v", want, file, charge)
+		}
+		for ctl, v := range want {
+			if charge[ctl] != v {
+				t.Errorf("want charge %f on controller %d from %s, got %f", v, ctl, file, charge[ctl])
+			}
+		}
+	}
+}
+
+func TestStorcliCache(t *testing.T) {
+	for cache, want := range map[string][3]string{
+		"RWBD":  {"read_ahead", "write_back", "direct"},
+		"NRWTD": {"no_read_ahead", "write_through", "direct"},
+		"RAWBC": {"read_ahead", "always_write_back", "cached"},
+	} {
+		readPolicy, writePolicy, ioPolicy, err := parseStorcliCache(cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := [3]string{readPolicy, writePolicy, ioPolicy}; got != want {
+			t.Errorf("want cache policy %v for %s, got %v", want, cache, got)
+		}
+	}
+
+	if _, _, _, err := parseStorcliCache("-"); err == nil {
+		t.Error("want error for invalid cache policy")
+	}
+}
+
+// Returns the values of the collected metrics by name and labels, e.g.
+// "node_megacli_bbu_optimal controller=0 type=cachevault".
+func updateStorcliCollector(c Collector) (map[string]float64, error) {
+	ch := make(chan prometheus.Metric, 1000)
+	if err := c.Update(ch); err != nil {
+		return nil, err
+	}
+	close(ch)
+
+	metrics := map[string]float64{}
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			return nil, err
+		}
+		name := storcliDescNameRE.FindStringSubmatch(m.Desc().String())[1]
+		for _, l := range metric.GetLabel() {
+			name += fmt.Sprintf(" %s=%s", l.GetName(), l.GetValue())
+		}
+		metrics[name] = metric.GetGauge().GetValue() + metric.GetCounter().GetValue()
+	}
+	return metrics, nil
+}
+
+var storcliDescNameRE = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+func TestStorcliCollector(t *testing.T) {
+	if err := flag.Set("collector.megacli.storcli-command", "./fixtures/storcli"); err != nil {
+		t.Fatal(err)
+	}
+	defer flag.Set("collector.megacli.storcli-command", "")
+
+	collector, err := NewMegaCliCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metrics, err := updateStorcliCollector(collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]float64{
+		"node_megacli_controller_optimal controller=0":                                                                                  1,
+		"node_megacli_controller_alarm controller=1":                                                                                    1,
+		"node_megacli_controller_temperature_celsius controller=0":                                                                      63,
+		"node_megacli_controller_memory_errors controller=1 type=uncorrectable":                                                         1,
+		"node_megacli_virtual_drive_state controller=0 drive_group=1 state=degraded virtual_drive=1":                                    1,
+		"node_megacli_virtual_drive_state controller=0 drive_group=1 state=optimal virtual_drive=1":                                     0,
+		"node_megacli_virtual_drive_cache_policy controller=0 drive_group=0 io=direct read=read_ahead virtual_drive=0 write=write_back": 1,
+		"node_megacli_physical_drive_state controller=0 enclosure=32 slot=3 state=rebuild":                                              1,
+		"node_megacli_physical_drive_state controller=1 enclosure= slot=1 state=global_hot_spare":                                       1,
+		"node_megacli_bbu_optimal controller=0 type=cachevault":                                                                         1,
+		"node_megacli_bbu_optimal controller=1 type=bbu":                                                                                0,
+		"node_megacli_bbu_temperature_celsius controller=0 type=cachevault":                                                             28,
+		"node_megacli_bbu_temperature_celsius controller=1 type=bbu":                                                                    31,
+		"node_megacli_bbu_charge_ratio controller=0 type=cachevault":                                                                    0.97,
+		"node_megacli_bbu_charge_ratio controller=1 type=bbu":                                                                           0.64,
+	} {
+		if got, ok := metrics[name]; !ok || want != got {
+			t.Errorf("want %s %f, got %f", name, want, got)
+		}
+	}
+
+	// After the CacheVault failed, the virtual drive fell back to write
+	// through and the other one was removed.
+	os.Setenv("STORCLI_SHOW_ALL", "storcli_show_all_cv_failed.json")
+	defer os.Unsetenv("STORCLI_SHOW_ALL")
+
+	metrics, err = updateStorcliCollector(collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1.0, metrics["node_megacli_virtual_drive_cache_policy controller=0 drive_group=0 io=direct read=read_ahead virtual_drive=0 write=write_through"]; want != got {
+		t.Errorf("want write through cache policy %f, got %f", want, got)
+	}
+	if want, got := 0.0, metrics["node_megacli_bbu_optimal controller=0 type=cachevault"]; want != got {
+		t.Errorf("want failed CacheVault %f, got %f", want, got)
+	}
+	for _, name := range []string{
+		"node_megacli_virtual_drive_cache_policy controller=0 drive_group=0 io=direct read=read_ahead virtual_drive=0 write=write_back",
+		"node_megacli_virtual_drive_state controller=0 drive_group=1 state=degraded virtual_drive=1",
+		"node_megacli_bbu_temperature_celsius controller=0 type=cachevault",
+	} {
+		if got, ok := metrics[name]; ok {
+			t.Errorf("want %s to be gone, got %f", name, got)
+		}
+	}
+	if want, got := 31.0, metrics["node_megacli_bbu_temperature_celsius controller=1 type=bbu"]; want != got {
+		t.Errorf("want BBU temperature %f, got %f", want, got)
+	}
+}
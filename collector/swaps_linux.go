@@ -0,0 +1,136 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noswaps
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	swapsSubsystem = "swap"
+)
+
+// Octal escapes of whitespace and backslashes in paths, e.g. \040.
+var swapsEscapeRE = regexp.MustCompile(`\\[0-7]{3}`)
+
+type swapDevice struct {
+	device, typ          string
+	size, used, priority float64
+}
+
+type swapsCollector struct {
+	sizeDesc, usedDesc, priorityDesc *prometheus.Desc
+}
+
+func init() {
+	Factories["swaps"] = NewSwapsCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// the size, usage and priority of each swap device.
+func NewSwapsCollector() (Collector, error) {
+	labels := []string{"device", "type"}
+	return &swapsCollector{
+		sizeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, swapsSubsystem, "size_bytes"),
+			"Size of the swap device.",
+			labels, nil,
+		),
+		usedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, swapsSubsystem, "used_bytes"),
+			"Used space of the swap device.",
+			labels, nil,
+		),
+		priorityDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, swapsSubsystem, "priority"),
+			"Priority of the swap device, higher priority devices are used first.",
+			labels, nil,
+		),
+	}, nil
+}
+
+func (c *swapsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	swaps, err := getSwaps()
+	if err != nil {
+		return fmt.Errorf("couldn't get swaps: %s", err)
+	}
+	for _, s := range swaps {
+		ch <- prometheus.MustNewConstMetric(c.sizeDesc, prometheus.GaugeValue, s.size, s.device, s.typ)
+		ch <- prometheus.MustNewConstMetric(c.usedDesc, prometheus.GaugeValue, s.used, s.device, s.typ)
+		ch <- prometheus.MustNewConstMetric(c.priorityDesc, prometheus.GaugeValue, s.priority, s.device, s.typ)
+	}
+	return nil
+}
+
+func getSwaps() ([]swapDevice, error) {
+	file, err := os.Open(procFilePath("swaps"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseSwaps(file)
+}
+
+// Parses /proc/swaps, which has a header followed by lines like
+// "/dev/dm-1 partition 16760828 1203712 -2". Sizes are in KiB.
+func parseSwaps(r io.Reader) ([]swapDevice, error) {
+	var (
+		swaps   []swapDevice
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 || parts[0] == "Filename" {
+			continue
+		}
+		if len(parts) != 5 {
+			return nil, fmt.Errorf("invalid line in swaps: %s", scanner.Text())
+		}
+
+		s := swapDevice{
+			device: swapsEscapeRE.ReplaceAllStringFunc(parts[0], func(e string) string {
+				c, err := strconv.ParseUint(e[1:], 8, 8)
+				if err != nil {
+					// Out of range of a byte like \777.
+					return e
+				}
+				return string(byte(c))
+			}),
+			typ: parts[1],
+		}
+		for i, value := range []*float64{&s.size, &s.used, &s.priority} {
+			v, err := strconv.ParseFloat(parts[i+2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s in swaps: %s", parts[i+2], err)
+			}
+			*value = v
+		}
+		s.size *= 1024
+		s.used *= 1024
+		swaps = append(swaps, s)
+	}
+	return swaps, scanner.Err()
+}
@@ -0,0 +1,61 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSwaps(t *testing.T) {
+	file, err := os.Open("fixtures/proc/swaps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	swaps, err := parseSwaps(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 3, len(swaps); want != got {
+		t.Fatalf("want %d swap devices, got %d", want, got)
+	}
+
+	if want, got := (swapDevice{device: "/dev/dm-1", typ: "partition", size: 17163087872, used: 1232601088, priority: -2}), swaps[0]; want != got {
+		t.Errorf("want swap device %v, got %v", want, got)
+	}
+
+	if want, got := "/var/lib/swap file", swaps[1].device; want != got {
+		t.Errorf("want unescaped device %q, got %q", want, got)
+	}
+}
+
+func TestSwapsEscapes(t *testing.T) {
+	swaps, err := parseSwaps(strings.NewReader(`Filename	Type	Size	Used	Priority
+/swap\134\777\040file                       file		1048572	0	-3
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1, len(swaps); want != got {
+		t.Fatalf("want %d swap devices, got %d", want, got)
+	}
+	if want, got := `/swap\\777 file`, swaps[0].device; want != got {
+		t.Errorf("want unescaped device %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nosysctl
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	sysctlSubsystem = "sysctl"
+)
+
+var (
+	sysctlInclude = flag.String(
+		"collector.sysctl.include",
+		"net.core.somaxconn,vm.swappiness,fs.inotify.max_user_watches,kernel.pid_max,net.ipv4.ip_local_port_range:min:max",
+		"Comma-separated sysctl keys to export, which may contain glob patterns whose matches are exported with a key label. Values of keys with multiple values are exported by index, or by name if the names are appended to the key like key:name1:name2.",
+	)
+
+	sysctlInvalidRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+	sysctlGlobRE    = regexp.MustCompile(`\[[^]]*\]|[*?]`)
+)
+
+// A key of the include flag, with the names of its values if any. The
+// sysctls matching a glob pattern share a metric with a key label.
+type sysctlKey struct {
+	pattern string
+	names   []string
+	metric  string
+	glob    bool
+}
+
+// The value of a sysctl. String values are those that aren't entirely
+// numeric.
+type sysctlValue struct {
+	key     string
+	names   []string
+	numbers []float64
+	str     string
+}
+
+type sysctlCollector struct {
+	keys                             []sysctlKey
+	infoDesc, missingDesc, errorDesc *prometheus.Desc
+}
+
+func init() {
+	Factories["sysctl"] = NewSysctlCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// the values of sysctl keys.
+func NewSysctlCollector() (Collector, error) {
+	keys, err := parseSysctlKeys(*sysctlInclude)
+	if err != nil {
+		return nil, err
+	}
+	return &sysctlCollector{
+		keys: keys,
+		infoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sysctlSubsystem, "info"),
+			"Value of a sysctl that isn't numeric.",
+			[]string{"name", "value"}, nil,
+		),
+		missingDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sysctlSubsystem, "key_missing"),
+			"Whether no sysctl matches the configured key.",
+			[]string{"key"}, nil,
+		),
+		errorDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sysctlSubsystem, "key_error"),
+			"Whether reading a sysctl matching the configured key failed.",
+			[]string{"key"}, nil,
+		),
+	}, nil
+}
+
+func (c *sysctlCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	for _, key := range c.keys {
+		values, failed, err := getSysctls(key)
+		if err != nil {
+			return fmt.Errorf("couldn't get sysctl %s: %s", key.pattern, err)
+		}
+
+		var missing, failure float64
+		if len(values) == 0 && !failed {
+			log.Debugf("No sysctl matches %s", key.pattern)
+			missing = 1
+		}
+		if failed {
+			failure = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.missingDesc, prometheus.GaugeValue, missing, key.pattern)
+		ch <- prometheus.MustNewConstMetric(c.errorDesc, prometheus.GaugeValue, failure, key.pattern)
+
+		for _, v := range values {
+			if v.numbers == nil {
+				ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, v.key, v.str)
+				continue
+			}
+			if err := c.updateNumbers(ch, key, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Exports the numbers of a sysctl, e.g. node_sysctl_vm_swappiness for a
+// single one, node_sysctl_fs_file_nr{index="0"} for several ones and
+// node_sysctl_net_ipv4_ip_local_port_range_min for named ones. Sysctls of
+// a glob pattern like net.ipv4.conf.*.rp_filter are exported as
+// node_sysctl_net_ipv4_conf_rp_filter{key="net.ipv4.conf.eth0.rp_filter"}.
+func (c *sysctlCollector) updateNumbers(ch chan<- prometheus.Metric, key sysctlKey, v sysctlValue) error {
+	var (
+		name        = prometheus.BuildFQName(Namespace, sysctlSubsystem, key.metric)
+		help        = fmt.Sprintf("sysctl %s.", key.pattern)
+		labels      []string
+		labelValues []string
+	)
+	if key.glob {
+		labels, labelValues = []string{"key"}, []string{v.key}
+	}
+
+	switch {
+	case len(v.numbers) == 1 && v.names == nil:
+		desc := prometheus.NewDesc(name, help, labels, nil)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v.numbers[0], labelValues...)
+	case v.names == nil:
+		desc := prometheus.NewDesc(name, help, append(labels, "index"), nil)
+		for i, n := range v.numbers {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, n, append(labelValues, strconv.Itoa(i))...)
+		}
+	case len(v.names) == len(v.numbers):
+		for i, n := range v.numbers {
+			desc := prometheus.NewDesc(name+"_"+v.names[i], help, labels, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, n, labelValues...)
+		}
+	default:
+		return fmt.Errorf("sysctl %s has %d values, but %d names are configured", v.key, len(v.numbers), len(v.names))
+	}
+	return nil
+}
+
+// Parses keys like "vm.swappiness,net.ipv4.ip_local_port_range:min:max".
+func parseSysctlKeys(s string) ([]sysctlKey, error) {
+	var (
+		keys    []sysctlKey
+		metrics = map[string]string{} // metric -> pattern
+	)
+	for _, k := range strings.Split(s, ",") {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		parts := strings.Split(k, ":")
+		key := sysctlKey{pattern: parts[0]}
+		key.metric, key.glob = sysctlMetricName(key.pattern)
+		if len(parts) > 1 {
+			key.names = parts[1:]
+		}
+		if _, err := filepath.Match(sysctlPath(key.pattern), ""); err != nil {
+			return nil, fmt.Errorf("invalid sysctl key %s: %s", key.pattern, err)
+		}
+		for _, name := range key.names {
+			if name == "" || sysctlInvalidRE.MatchString(name) {
+				return nil, fmt.Errorf("invalid value name %q of sysctl key %s", name, key.pattern)
+			}
+		}
+
+		names := []string{key.metric}
+		if key.names != nil {
+			names = nil
+			for _, name := range key.names {
+				names = append(names, key.metric+"_"+name)
+			}
+		}
+		for _, name := range names {
+			if other, ok := metrics[name]; ok {
+				return nil, fmt.Errorf("sysctl keys %s and %s have the same metric name %s", other, key.pattern, name)
+			}
+			metrics[name] = key.pattern
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Returns the metric name of a key and whether it's a glob pattern, whose
+// wildcards are left out, e.g. net_ipv4_conf_rp_filter for
+// net.ipv4.conf.*.rp_filter.
+func sysctlMetricName(pattern string) (string, bool) {
+	if !sysctlGlobRE.MatchString(pattern) {
+		return sysctlInvalidRE.ReplaceAllString(pattern, "_"), false
+	}
+	var parts []string
+	for _, part := range strings.Split(sysctlGlobRE.ReplaceAllString(pattern, ""), ".") {
+		if part != "" {
+			parts = append(parts, sysctlInvalidRE.ReplaceAllString(part, "_"))
+		}
+	}
+	return strings.Join(parts, "_"), true
+}
+
+// Returns the values of the sysctls matching the key, and whether reading
+// any of them failed. Sysctls that can't be read are skipped.
+func getSysctls(key sysctlKey) ([]sysctlValue, bool, error) {
+	base := procFilePath("sys")
+	files, err := filepath.Glob(filepath.Join(base, sysctlPath(key.pattern)))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var (
+		values = make([]sysctlValue, 0, len(files))
+		failed bool
+	)
+	for _, file := range files {
+		rel, err := filepath.Rel(base, file)
+		if err != nil {
+			return nil, false, err
+		}
+		name := sysctlPath(rel)
+
+		info, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			log.Errorf("Couldn't read sysctl %s: %s", name, err)
+			failed = true
+			continue
+		}
+		if info.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(file)
+		// Some sysctls like vm.drop_caches are write-only.
+		if os.IsPermission(err) {
+			log.Debugf("Ignoring sysctl %s: %s", name, err)
+			continue
+		}
+		// Others fail with e.g. EIO or EINVAL if unsupported.
+		if err != nil {
+			log.Errorf("Couldn't read sysctl %s: %s", name, err)
+			failed = true
+			continue
+		}
+		v := parseSysctlValue(string(data))
+		v.key, v.names = name, key.names
+		values = append(values, v)
+	}
+	return values, failed, nil
+}
+
+func parseSysctlValue(data string) sysctlValue {
+	v := sysctlValue{str: strings.TrimSpace(data)}
+	for _, field := range strings.Fields(data) {
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return sysctlValue{str: v.str}
+		}
+		v.numbers = append(v.numbers, n)
+	}
+	return v
+}
+
+// Converts between sysctl keys and their paths below /proc/sys. Dots in
+// the components of a key, e.g. of VLAN interfaces, are written as
+// slashes, so the conversion is its own inverse.
+func sysctlPath(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.':
+			return '/'
+		case '/':
+			return '.'
+		}
+		return r
+	}, s)
+}
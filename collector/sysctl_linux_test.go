@@ -0,0 +1,213 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSysctl(t *testing.T) {
+	defer flag.Set("collector.procfs", *procPath)
+	if err := flag.Set("collector.procfs", "fixtures/proc"); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := parseSysctlKeys("vm.swappiness, net.ipv4.conf.*.rp_filter,net.ipv4.ip_local_port_range:min:max,kernel.core_pattern,vm.nonexistent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 5, len(keys); want != got {
+		t.Fatalf("want %d keys, got %d", want, got)
+	}
+
+	values, _, err := getSysctls(keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 60.0, values[0].numbers[0]; len(values) != 1 || want != got {
+		t.Errorf("want swappiness %f, got %v", want, values)
+	}
+
+	values, _, err = getSysctls(keys[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 3, len(values); want != got {
+		t.Fatalf("want %d rp_filter sysctls, got %d", want, got)
+	}
+
+	if want, got := "net.ipv4.conf.eth0/100.rp_filter", values[2].key; want != got {
+		t.Errorf("want key %s, got %s", want, got)
+	}
+
+	values, _, err = getSysctls(keys[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 60999.0, values[0].numbers[1]; want != got {
+		t.Errorf("want port range max %f, got %f", want, got)
+	}
+
+	if want, got := []string{"min", "max"}, values[0].names; len(got) != 2 || want[1] != got[1] {
+		t.Errorf("want names %v, got %v", want, got)
+	}
+
+	values, _, err = getSysctls(keys[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "|/usr/share/apport/apport -p%p -s%s -c%c -d%d -P%P -u%u -g%g -- %E", values[0].str; values[0].numbers != nil || want != got {
+		t.Errorf("want string value %q, got %v", want, values[0])
+	}
+
+	values, _, err = getSysctls(keys[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 0, len(values); want != got {
+		t.Errorf("want no values for missing key, got %v", values)
+	}
+
+	if _, err := parseSysctlKeys("vm.swappiness:a-b"); err == nil {
+		t.Error("want error for invalid value name")
+	}
+
+	if want, got := "net_ipv4_conf_rp_filter", keys[1].metric; !keys[1].glob || want != got {
+		t.Errorf("want glob metric %s, got %+v", want, keys[1])
+	}
+
+	for _, include := range []string{
+		"net.ipv4.conf.eth0/100.rp_filter,net.ipv4.conf.eth0_100.rp_filter",
+		"net.ipv4.conf.*.rp_filter,net.ipv4.conf.?.rp_filter",
+		"net.ipv4.ip_local_port_range:min:max,net.ipv4.ip_local_port_range_max",
+	} {
+		if _, err := parseSysctlKeys(include); err == nil {
+			t.Errorf("want error for keys with the same metric name: %s", include)
+		}
+	}
+}
+
+// Returns the values of the sysctl metrics by name and labels.
+func updateSysctl(include string) (map[string]float64, error) {
+	defer flag.Set("collector.sysctl.include", *sysctlInclude)
+	if err := flag.Set("collector.sysctl.include", include); err != nil {
+		return nil, err
+	}
+	c, err := NewSysctlCollector()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan prometheus.Metric, 100)
+	if err := c.Update(ch); err != nil {
+		return nil, err
+	}
+	close(ch)
+
+	metrics := map[string]float64{}
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			return nil, err
+		}
+		name := sysctlDescNameRE.FindStringSubmatch(m.Desc().String())[1]
+		for _, l := range metric.GetLabel() {
+			name += fmt.Sprintf(" %s=%s", l.GetName(), l.GetValue())
+		}
+		metrics[name] = metric.GetGauge().GetValue()
+	}
+	return metrics, nil
+}
+
+var sysctlDescNameRE = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+func TestSysctlUpdate(t *testing.T) {
+	defer flag.Set("collector.procfs", *procPath)
+	if err := flag.Set("collector.procfs", "fixtures/proc"); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics, err := updateSysctl("net.ipv4.conf.*.rp_filter,fs.file-nr,vm.nonexistent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]float64{
+		"node_sysctl_net_ipv4_conf_rp_filter key=net.ipv4.conf.all.rp_filter":      2,
+		"node_sysctl_net_ipv4_conf_rp_filter key=net.ipv4.conf.eth0/100.rp_filter": 0,
+		"node_sysctl_fs_file_nr index=2":                                           1631329,
+		"node_sysctl_key_missing key=vm.nonexistent":                               1,
+		"node_sysctl_key_error key=fs.file-nr":                                     0,
+	} {
+		if got, ok := metrics[name]; !ok || want != got {
+			t.Errorf("want %s %f, got %f", name, want, got)
+		}
+	}
+}
+
+func TestSysctlReadError(t *testing.T) {
+	root, err := ioutil.TempDir("", "node_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "sys/vm")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "swappiness"), []byte("60\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Fails with ELOOP, as root can read files regardless of permissions.
+	if err := os.Symlink("broken", filepath.Join(dir, "broken")); err != nil {
+		t.Fatal(err)
+	}
+
+	defer flag.Set("collector.procfs", *procPath)
+	if err := flag.Set("collector.procfs", root); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics, err := updateSysctl("vm.*,vm.broken")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]float64{
+		"node_sysctl_vm key=vm.swappiness":      60,
+		"node_sysctl_key_error key=vm.*":        1,
+		"node_sysctl_key_error key=vm.broken":   1,
+		"node_sysctl_key_missing key=vm.broken": 0,
+	} {
+		if got, ok := metrics[name]; !ok || want != got {
+			t.Errorf("want %s %f, got %f", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noxfs
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	xfsSubsystem = "xfs"
+)
+
+// Groups of /proc/fs/xfs/stat, in the order of the values on each line.
+// See http://xfs.org/index.php/Runtime_Stats for details.
+var xfsStatGroups = []struct {
+	key, name string
+	fields    []string
+}{
+	{"extent_alloc", "extent_allocation", []string{"extents_allocated", "blocks_allocated", "extents_freed", "blocks_freed"}},
+	{"blk_map", "block_mapping", []string{"reads", "writes", "unmaps", "extent_list_insertions", "extent_list_deletions", "extent_list_lookups", "extent_list_compares"}},
+	{"dir", "directory_operation", []string{"lookup", "create", "remove", "getdents"}},
+	{"trans", "transaction", []string{"sync", "async", "empty"}},
+	{"ig", "inode_operation", []string{"attempts", "found", "recycled", "missed", "duplicates", "reclaims", "attribute_changes"}},
+	{"log", "log_operation", []string{"writes", "blocks", "noiclogs", "force", "force_sleep"}},
+	{"buf", "buffer", []string{"get", "create", "get_locked", "get_locked_waited", "busy_locked", "miss_locked", "page_retries", "page_found", "get_read"}},
+}
+
+type xfsCollector struct {
+	metricDescs map[string][]*prometheus.Desc
+}
+
+func init() {
+	Factories["xfs"] = NewXFSCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// XFS statistics.
+func NewXFSCollector() (Collector, error) {
+	descs := map[string][]*prometheus.Desc{}
+	for _, g := range xfsStatGroups {
+		for _, f := range g.fields {
+			descs[g.key] = append(descs[g.key], prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, xfsSubsystem, g.name+"_"+f+"_total"),
+				fmt.Sprintf("XFS %s statistic %s.", strings.Replace(g.name, "_", " ", -1), f),
+				[]string{"device"}, nil,
+			))
+		}
+	}
+	return &xfsCollector{metricDescs: descs}, nil
+}
+
+func (c *xfsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	stats, err := getXFSStats()
+	if err != nil {
+		return fmt.Errorf("couldn't get XFS stats: %s", err)
+	}
+	for dev, devStats := range stats {
+		for _, g := range xfsStatGroups {
+			// Only export the values the kernel provides, older kernels
+			// have fewer values per line or lack lines altogether.
+			for i, v := range devStats[g.key] {
+				if i >= len(c.metricDescs[g.key]) {
+					break
+				}
+				ch <- prometheus.MustNewConstMetric(c.metricDescs[g.key][i], prometheus.CounterValue, v, dev)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the statistics per device from /sys/fs/xfs/<dev>/stats/stats. Kernels
+// without per device statistics only provide /proc/fs/xfs/stat, which is
+// returned with an empty device.
+func getXFSStats() (map[string]map[string][]float64, error) {
+	stats := map[string]map[string][]float64{}
+
+	devices, err := filepath.Glob(sysFilePath("fs/xfs/*/stats/stats"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dev := range devices {
+		s, err := readXFSStats(dev)
+		if err != nil {
+			return nil, err
+		}
+		stats[path.Base(path.Dir(path.Dir(dev)))] = s
+	}
+	if len(stats) > 0 {
+		return stats, nil
+	}
+
+	s, err := readXFSStats(procFilePath("fs/xfs/stat"))
+	if os.IsNotExist(err) {
+		log.Debugf("Not collecting XFS stats, no XFS filesystem mounted")
+		return stats, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stats[""] = s
+	return stats, nil
+}
+
+func readXFSStats(name string) (map[string][]float64, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseXFSStats(file)
+}
+
+func parseXFSStats(r io.Reader) (map[string][]float64, error) {
+	var (
+		stats   = map[string][]float64{}
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 {
+			continue
+		}
+		values := make([]float64, 0, len(parts)-1)
+		for _, p := range parts[1:] {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s in XFS stats: %s", p, err)
+			}
+			values = append(values, v)
+		}
+		stats[parts[0]] = values
+	}
+	return stats, scanner.Err()
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"os"
+	"testing"
+)
+
+func TestXFSStats(t *testing.T) {
+	file, err := os.Open("fixtures/proc/fs/xfs/stat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stats, err := parseXFSStats(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 97589.0, stats["extent_alloc"][1]; want != got {
+		t.Errorf("want XFS blocks allocated %f, got %f", want, got)
+	}
+
+	if want, got := 944304.0, stats["trans"][1]; want != got {
+		t.Errorf("want XFS async transactions %f, got %f", want, got)
+	}
+
+	if want, got := 9, len(stats["buf"]); want != got {
+		t.Errorf("want %d XFS buffer values, got %d", want, got)
+	}
+}
+
+func TestXFSDeviceStats(t *testing.T) {
+	if err := flag.Set("collector.sysfs", "fixtures/sys"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := getXFSStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 2, len(stats); want != got {
+		t.Fatalf("want XFS stats of %d devices, got %d", want, got)
+	}
+
+	if want, got := 123203.0, stats["sda1"]["dir"][0]; want != got {
+		t.Errorf("want XFS directory lookups %f on sda1, got %f", want, got)
+	}
+
+	if _, ok := stats["sdb1"]["trans"]; ok {
+		t.Error("want no XFS transaction stats on sdb1")
+	}
+
+	if want, got := 6, len(stats["sdb1"]["buf"]); want != got {
+		t.Errorf("want %d XFS buffer values on sdb1, got %d", want, got)
+	}
+}
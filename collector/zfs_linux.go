@@ -0,0 +1,288 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nozfs
+
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	zfsSubsystem = "zfs"
+	zfsKstatPath = "spl/kstat/zfs"
+)
+
+var (
+	// Named kstats to export, by file name below /proc/spl/kstat/zfs and
+	// the metric prefix to use.
+	zfsKstats = []struct{ file, name string }{
+		{"arcstats", "arc"},
+		{"zfetchstats", "zfetch"},
+		{"dmu_tx", "dmu_tx"},
+		{"vdev_cache_stats", "vdev_cache"},
+		{"zil", "zil"},
+	}
+
+	// Named kstats which are gauges. Besides these, all sizes are gauges
+	// and all other kstats are counters.
+	zfsKstatGauges = map[string]bool{
+		"arc_p": true, "arc_c": true, "arc_c_min": true, "arc_c_max": true,
+		"arc_hash_elements": true, "arc_hash_elements_max": true,
+		"arc_hash_chains": true, "arc_hash_chain_max": true,
+		"arc_no_grow": true, "arc_tempreserve": true, "arc_loaned_bytes": true,
+		"arc_need_free": true, "arc_sys_free": true, "arc_meta_used": true,
+		"arc_meta_limit": true, "arc_meta_max": true, "arc_meta_min": true,
+		"arc_dnode_limit": true, "arc_memory_all_bytes": true,
+		"arc_memory_free_bytes": true, "arc_memory_available_bytes": true,
+	}
+
+	// States of a pool as exported by node_zfs_pool_state.
+	zfsPoolStates = []string{"online", "degraded", "faulted", "offline", "removed", "unavail", "suspended"}
+
+	// Columns of the pool io kstat, see kstat_io_t.
+	zfsPoolIOFields = []struct {
+		column, name, help string
+		valueType          prometheus.ValueType
+		divisor            float64
+	}{
+		{"nread", "read_bytes_total", "Number of bytes read from pool.", prometheus.CounterValue, 1},
+		{"nwritten", "written_bytes_total", "Number of bytes written to pool.", prometheus.CounterValue, 1},
+		{"reads", "reads_total", "Number of read operations on pool.", prometheus.CounterValue, 1},
+		{"writes", "writes_total", "Number of write operations on pool.", prometheus.CounterValue, 1},
+		{"wtime", "wait_seconds_total", "Time spent with operations in the wait queue of pool.", prometheus.CounterValue, 1e9},
+		{"wlentime", "wait_length_seconds_total", "Sum of wait queue length multiplied by time spent at that length for pool.", prometheus.CounterValue, 1e9},
+		{"rtime", "run_seconds_total", "Time spent with operations in the run queue of pool.", prometheus.CounterValue, 1e9},
+		{"rlentime", "run_length_seconds_total", "Sum of run queue length multiplied by time spent at that length for pool.", prometheus.CounterValue, 1e9},
+		{"wcnt", "wait_queue_length", "Number of operations in the wait queue of pool.", prometheus.GaugeValue, 1},
+		{"rcnt", "run_queue_length", "Number of operations in the run queue of pool.", prometheus.GaugeValue, 1},
+	}
+)
+
+type zfsCollector struct {
+	metricDescs map[string]*prometheus.Desc
+	poolIODescs []*prometheus.Desc
+	poolState   *prometheus.Desc
+}
+
+func init() {
+	Factories["zfs"] = NewZFSCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// ZFS on Linux kstat statistics.
+func NewZFSCollector() (Collector, error) {
+	poolIODescs := make([]*prometheus.Desc, 0, len(zfsPoolIOFields))
+	for _, f := range zfsPoolIOFields {
+		poolIODescs = append(poolIODescs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, zfsSubsystem, "pool_"+f.name),
+			f.help, []string{"pool"}, nil,
+		))
+	}
+	return &zfsCollector{
+		metricDescs: map[string]*prometheus.Desc{},
+		poolIODescs: poolIODescs,
+		poolState: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, zfsSubsystem, "pool_state"),
+			"Indicates the state of pool.",
+			[]string{"pool", "state"}, nil,
+		),
+	}, nil
+}
+
+func (c *zfsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	if _, err := os.Stat(procFilePath(zfsKstatPath)); os.IsNotExist(err) {
+		log.Debugf("Not collecting ZFS stats, ZFS module not loaded")
+		return nil
+	}
+
+	for _, k := range zfsKstats {
+		kstats, err := readZFSKstat(procFilePath(path.Join(zfsKstatPath, k.file)))
+		if os.IsNotExist(err) {
+			log.Debugf("Not collecting ZFS kstat %s, file does not exist", k.file)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't get ZFS kstat %s: %s", k.file, err)
+		}
+		for name, value := range kstats {
+			// dmu_tx and zil prefix their kstats with the kstat name.
+			key := k.name + "_" + strings.TrimPrefix(name, k.name+"_")
+			desc, ok := c.metricDescs[key]
+			if !ok {
+				desc = prometheus.NewDesc(
+					prometheus.BuildFQName(Namespace, zfsSubsystem, key),
+					fmt.Sprintf("ZFS %s kstat %s.", k.file, name),
+					nil, nil,
+				)
+				c.metricDescs[key] = desc
+			}
+			valueType := prometheus.CounterValue
+			if zfsKstatGauges[key] || strings.Contains(key, "size") || strings.Contains(key, "evictable") {
+				valueType = prometheus.GaugeValue
+			}
+			ch <- prometheus.MustNewConstMetric(desc, valueType, value)
+		}
+	}
+
+	pools, err := filepath.Glob(procFilePath(path.Join(zfsKstatPath, "*", "io")))
+	if err != nil {
+		return err
+	}
+	for _, p := range pools {
+		pool := path.Base(path.Dir(p))
+		if err := c.updatePool(ch, pool, path.Dir(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *zfsCollector) updatePool(ch chan<- prometheus.Metric, pool, dir string) error {
+	file, err := os.Open(path.Join(dir, "io"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	poolIO, err := parseZFSPoolIO(file)
+	if err != nil {
+		return fmt.Errorf("couldn't get io stats of pool %s: %s", pool, err)
+	}
+	for i, f := range zfsPoolIOFields {
+		v, ok := poolIO[f.column]
+		if !ok {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.poolIODescs[i], f.valueType, v/f.divisor, pool)
+	}
+
+	// The state kstat was added in ZFS on Linux 0.8.
+	state, err := ioutil.ReadFile(path.Join(dir, "state"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	current := strings.ToLower(strings.TrimSpace(string(state)))
+	for _, s := range zfsPoolStates {
+		var v float64
+		if s == current {
+			v = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.poolState, prometheus.GaugeValue, v, pool, s)
+	}
+	return nil
+}
+
+func readZFSKstat(name string) (map[string]float64, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseZFSKstat(file)
+}
+
+// Parses a named kstat, which consists of a header line, a line with the
+// column names and one line per kstat with its name, data type and value.
+func parseZFSKstat(r io.Reader) (map[string]float64, error) {
+	var (
+		kstats  = map[string]float64{}
+		scanner = bufio.NewScanner(r)
+	)
+
+	// Skip the kstat header and the column names.
+	for i := 0; i < 2; i++ {
+		if !scanner.Scan() {
+			return nil, errors.New("kstat empty")
+		}
+	}
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid line in kstat: %s", scanner.Text())
+		}
+
+		var (
+			value float64
+			err   error
+		)
+		// See KSTAT_DATA_* in the spl sources.
+		switch parts[1] {
+		case "1", "3": // signed integers
+			var v int64
+			v, err = strconv.ParseInt(parts[2], 10, 64)
+			value = float64(v)
+		case "2", "4": // unsigned integers
+			var v uint64
+			v, err = strconv.ParseUint(parts[2], 10, 64)
+			value = float64(v)
+		default:
+			log.Debugf("Ignoring kstat %s of unsupported type %s", parts[0], parts[1])
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s in kstat: %s", parts[2], err)
+		}
+		kstats[parts[0]] = value
+	}
+	return kstats, scanner.Err()
+}
+
+// Parses an io kstat, which consists of a header line, a line with the
+// column names and a line with the values.
+func parseZFSPoolIO(r io.Reader) (map[string]float64, error) {
+	scanner := bufio.NewScanner(r)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) != 3 {
+		return nil, fmt.Errorf("expected 3 lines in io kstat, got %d", len(lines))
+	}
+
+	columns, values := strings.Fields(lines[1]), strings.Fields(lines[2])
+	if len(columns) != len(values) {
+		return nil, fmt.Errorf("mismatched columns and values in io kstat: %s", lines[2])
+	}
+
+	poolIO := map[string]float64{}
+	for i, c := range columns {
+		v, err := strconv.ParseFloat(values[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s in io kstat: %s", values[i], err)
+		}
+		poolIO[c] = v
+	}
+	return poolIO, nil
+}
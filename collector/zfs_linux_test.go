@@ -0,0 +1,142 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestZFSKstat(t *testing.T) {
+	file, err := os.Open("fixtures/proc/spl/kstat/zfs/arcstats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	kstats, err := parseZFSKstat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 8772612.0, kstats["hits"]; want != got {
+		t.Errorf("want ARC hits %f, got %f", want, got)
+	}
+
+	if want, got := 1254.0, kstats["l2_hits"]; want != got {
+		t.Errorf("want L2ARC hits %f, got %f", want, got)
+	}
+
+	if want, got := 1603939792.0, kstats["size"]; want != got {
+		t.Errorf("want ARC size %f, got %f", want, got)
+	}
+
+	file, err = os.Open("fixtures/proc/spl/kstat/zfs/zil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	kstats, err = parseZFSKstat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 18446744073709537686.0, kstats["zil_itx_needcopy_bytes"]; want != got {
+		t.Errorf("want ZIL needcopy bytes %f, got %f", want, got)
+	}
+
+	if _, err := parseZFSKstat(strings.NewReader("6 1 0x01 1 48 1 2\nname type data\nhits 4\n")); err == nil {
+		t.Error("want error for kstat without value")
+	}
+}
+
+func TestZFSPoolIO(t *testing.T) {
+	file, err := os.Open("fixtures/proc/spl/kstat/zfs/poolz1/io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	poolIO, err := parseZFSPoolIO(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 2826240.0, poolIO["nread"]; want != got {
+		t.Errorf("want pool bytes read %f, got %f", want, got)
+	}
+
+	if want, got := 156232419.0, poolIO["rlentime"]; want != got {
+		t.Errorf("want pool run length time %f, got %f", want, got)
+	}
+
+	if want, got := 1.0, poolIO["wcnt"]; want != got {
+		t.Errorf("want pool wait queue length %f, got %f", want, got)
+	}
+}
+
+var zfsDescNameRE = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+func TestZFSKstatTypes(t *testing.T) {
+	defer flag.Set("collector.procfs", *procPath)
+	if err := flag.Set("collector.procfs", "fixtures/proc"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewZFSCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan prometheus.Metric, 1000)
+	if err := c.Update(ch); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+
+	counters := map[string]bool{}
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatal(err)
+		}
+		match := zfsDescNameRE.FindStringSubmatch(m.Desc().String())
+		if match == nil {
+			t.Fatalf("no name in %s", m.Desc())
+		}
+		counters[match[1]] = metric.Counter != nil
+	}
+
+	for name, want := range map[string]bool{
+		"node_zfs_arc_memory_throttle_count": true,
+		"node_zfs_arc_hits":                  true,
+		"node_zfs_arc_size":                  false,
+		"node_zfs_arc_c_max":                 false,
+	} {
+		got, ok := counters[name]
+		if !ok {
+			t.Errorf("want metric %s, got none", name)
+			continue
+		}
+		if want != got {
+			t.Errorf("want %s to be a counter: %t, got %t", name, want, got)
+		}
+	}
+}
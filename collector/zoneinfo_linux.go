@@ -0,0 +1,150 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nozoneinfo
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	zoneInfoSubsystem = "zoneinfo"
+)
+
+var (
+	// Page counts of a zone following its "pages free" line.
+	zoneInfoFields = []struct {
+		key, name, help string
+	}{
+		{"free", "free_pages", "Number of free pages in the zone."},
+		{"min", "min_pages", "Watermark below which only atomic allocations may use the zone and direct reclaim starts."},
+		{"low", "low_pages", "Watermark below which kswapd starts reclaiming pages of the zone."},
+		{"high", "high_pages", "Watermark above which kswapd stops reclaiming pages of the zone."},
+		{"spanned", "spanned_pages", "Number of pages spanned by the zone, including holes."},
+		{"present", "present_pages", "Number of physical pages present in the zone."},
+		{"managed", "managed_pages", "Number of present pages managed by the buddy allocator."},
+	}
+)
+
+type zoneInfoZone struct {
+	node, zone string
+	values     map[string]float64 // key -> pages
+}
+
+type zoneInfoCollector struct {
+	descs []*prometheus.Desc
+}
+
+func init() {
+	Factories["zoneinfo"] = NewZoneInfoCollector
+}
+
+// Takes a prometheus registry and returns a new Collector exposing
+// the watermarks and page counts of memory zones.
+func NewZoneInfoCollector() (Collector, error) {
+	descs := make([]*prometheus.Desc, 0, len(zoneInfoFields))
+	for _, f := range zoneInfoFields {
+		descs = append(descs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, zoneInfoSubsystem, f.name),
+			f.help, []string{"node", "zone"}, nil,
+		))
+	}
+	return &zoneInfoCollector{descs: descs}, nil
+}
+
+func (c *zoneInfoCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	zones, err := getZoneInfo()
+	if err != nil {
+		return fmt.Errorf("couldn't get zoneinfo: %s", err)
+	}
+	for _, z := range zones {
+		for i, f := range zoneInfoFields {
+			if v, ok := z.values[f.key]; ok {
+				ch <- prometheus.MustNewConstMetric(c.descs[i], prometheus.GaugeValue, v, z.node, z.zone)
+			}
+		}
+	}
+	return nil
+}
+
+func getZoneInfo() ([]zoneInfoZone, error) {
+	file, err := os.Open(procFilePath("zoneinfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseZoneInfo(file)
+}
+
+// Parses /proc/zoneinfo, which has a "Node 0, zone Normal" line for each
+// zone, followed by per node and per zone statistics. The page counts are
+// in a block starting with "pages free 18553", later statistics like the
+// pagesets reuse some of the keys.
+func parseZoneInfo(r io.Reader) ([]zoneInfoZone, error) {
+	var (
+		zones   []zoneInfoZone
+		values  map[string]float64 // of the current zone
+		inPages bool
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		switch {
+		case len(parts) == 0:
+			continue
+		case parts[0] == "Node":
+			if len(parts) != 4 || parts[2] != "zone" {
+				return nil, fmt.Errorf("invalid line in zoneinfo: %s", scanner.Text())
+			}
+			values, inPages = map[string]float64{}, false
+			zones = append(zones, zoneInfoZone{
+				node:   strings.TrimSuffix(parts[1], ","),
+				zone:   parts[3],
+				values: values,
+			})
+			continue
+		case values == nil:
+			return nil, fmt.Errorf("invalid line in zoneinfo before first zone: %s", scanner.Text())
+		case len(parts) == 3 && parts[0] == "pages" && parts[1] == "free":
+			inPages = true
+			parts = parts[1:]
+		}
+
+		if !inPages {
+			continue
+		}
+		// The block ends with the first key that's not a page count, which
+		// is "protection:" on all kernels since its introduction.
+		if len(parts) != 2 || strings.HasSuffix(parts[0], ":") {
+			inPages = false
+			continue
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s in zoneinfo: %s", parts[1], err)
+		}
+		values[parts[0]] = v
+	}
+	return zones, scanner.Err()
+}
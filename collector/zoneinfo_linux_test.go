@@ -0,0 +1,62 @@
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestZoneInfo(t *testing.T) {
+	file, err := os.Open("fixtures/proc/zoneinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zones, err := parseZoneInfo(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 5, len(zones); want != got {
+		t.Fatalf("want %d zones, got %d", want, got)
+	}
+
+	normal := zones[2]
+	if want, got := "Normal", normal.zone; want != got {
+		t.Errorf("want zone %s, got %s", want, got)
+	}
+
+	for key, want := range map[string]float64{
+		"free":    18553,
+		"min":     11396,
+		"high":    17094,
+		"present": 7503872,
+		"managed": 7437799,
+	} {
+		if got := normal.values[key]; want != got {
+			t.Errorf("want %s pages %f, got %f", key, want, got)
+		}
+	}
+
+	// The pagesets have a "high:" count as well.
+	if want, got := 9174.0, zones[1].values["high"]; want != got {
+		t.Errorf("want high watermark %f, got %f", want, got)
+	}
+
+	if want, got := "1", zones[4].node; want != got {
+		t.Errorf("want node %s, got %s", want, got)
+	}
+}
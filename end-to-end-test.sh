@@ -3,22 +3,45 @@
 set -euf -o pipefail
 
 collectors=$(cat << COLLECTORS
+  buddyinfo
   conntrack
+  cpu_details
   diskstats
+  dmi
+  edac
   entropy
   filefd
+  hugepages
+  hwmon
+  interrupts
   ksmd
   loadavg
   mdadm
   meminfo
   meminfo_numa
+  netclass
   netdev
   netstat
+  os
+  power_supply
+  pressure
+  slabinfo
   sockstat
+  softirqs
+  softnet
   stat
+  swaps
+  sysctl
   textfile
+  zoneinfo
+  bcache
   bonding
+  btrfs
   megacli
+  nfs
+  nfsd
+  xfs
+  zfs
 COLLECTORS
 )
 
@@ -62,9 +85,12 @@ fi
 ./node_exporter \
   -collector.procfs="collector/fixtures/proc" \
   -collector.sysfs="collector/fixtures/sys" \
+  -collector.rootfs="collector/fixtures/rootfs" \
   -collectors.enabled="$(echo ${collectors} | tr ' ' ',')" \
   -collector.textfile.directory="collector/fixtures/textfile/two_metric_files/" \
   -collector.megacli.command="collector/fixtures/megacli" \
+  -collector.slabinfo.include="^(dentry|inode_cache|ext4_inode_cache)$" \
+  -collector.sysctl.include="net.core.somaxconn,vm.swappiness,kernel.pid_max,net.ipv4.ip_local_port_range:min:max,fs.file-nr,kernel.core_pattern,net.ipv4.conf.*.rp_filter,vm.nonexistent" \
   -web.listen-address "127.0.0.1:${port}" \
   -log.level="debug" > "${tmpdir}/node_exporter.log" 2>&1 &
 